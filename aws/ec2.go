@@ -17,6 +17,8 @@ limitations under the License.
 package aws
 
 import (
+	"fmt"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	common "github.com/cloudposse/turf/common/error"
@@ -69,3 +71,19 @@ func GetEnabledRegions(region string, role string, isPrivileged bool) []string {
 
 	return regionsList
 }
+
+// SelectRegions returns the selected regions after checking that each of them is enabled. All of the enabled regions
+// are returned when no regions are selected.
+func SelectRegions(enabledRegions []string, selectedRegions []string) ([]string, error) {
+	if len(selectedRegions) == 0 {
+		return enabledRegions, nil
+	}
+
+	for i := range selectedRegions {
+		if !validateRegion(enabledRegions, selectedRegions[i]) {
+			return nil, fmt.Errorf("%s is not a valid enabled region in this account", selectedRegions[i])
+		}
+	}
+
+	return selectedRegions, nil
+}
@@ -39,8 +39,16 @@ func enableGuardDutyAdminAccount(client *guardduty.GuardDuty, accountID string)
 // We need to enable GuardDuty in the AWS Organizations Management Account so that it can be added as a member
 // account in AWS GuardDuty's Administrator account. Accounts other than the Management Account don't need to be
 // excplicitly enabled, but the MA does.
-func enableGuardDutyInManagementAccount(client *guardduty.GuardDuty) {
-	_, err := client.CreateDetector(&guardduty.CreateDetectorInput{Enable: aws.Bool(true)})
+func enableGuardDutyInManagementAccount(client *guardduty.GuardDuty, settings GuardDutyDetectorSettings) {
+	input := guardduty.CreateDetectorInput{Enable: aws.Bool(true)}
+	if settings.FindingPublishingFrequency != "" {
+		input.FindingPublishingFrequency = aws.String(settings.FindingPublishingFrequency)
+	}
+	if len(settings.Tags) > 0 {
+		input.Tags = aws.StringMap(settings.Tags)
+	}
+
+	_, err := client.CreateDetector(&input)
 	if err != nil {
 		logrus.Error(err)
 	}
@@ -73,23 +81,78 @@ func logGuardDutyMemberAccounts(memberAccounts []AccountWithEmail) {
 	}
 }
 
-// addMemberAccount adds an account in the AWS Organization as a member of the GuardDuty Administrator Account
-func addGuardDutyMemberAccounts(client *guardduty.GuardDuty, detectorID string, memberAccounts []AccountWithEmail, administratorAcctID string) {
-	accountDetails := make([]*guardduty.AccountDetail, 0)
+// addGuardDutyMemberAccounts adds the accounts in the AWS Organization as members of the GuardDuty Administrator
+// Account and returns the final result of each account. CreateMembers accepts at most 50 accounts per request, so the
+// accounts are sent in batches.
+func addGuardDutyMemberAccounts(client *guardduty.GuardDuty, detectorID string, memberAccounts []AccountWithEmail, administratorAcctID string) map[string]string {
+	emails := make(map[string]string)
+	accountIDs := make([]string, 0)
 	for i := range memberAccounts {
-		currentAccountID := memberAccounts[i].AccountID
-		currentEmailAddress := memberAccounts[i].Email
-		if currentAccountID != administratorAcctID {
-			accountDetails = append(accountDetails, &guardduty.AccountDetail{AccountId: &currentAccountID, Email: aws.String(currentEmailAddress)})
+		if memberAccounts[i].AccountID != administratorAcctID {
+			emails[memberAccounts[i].AccountID] = memberAccounts[i].Email
+			accountIDs = append(accountIDs, memberAccounts[i].AccountID)
 		}
 	}
-	input := guardduty.CreateMembersInput{AccountDetails: accountDetails, DetectorId: aws.String(detectorID)}
-	result, err := client.CreateMembers(&input)
+
+	results := createMembersInBatches(accountIDs, func(batch []string) (map[string]string, error) {
+		accountDetails := make([]*guardduty.AccountDetail, 0, len(batch))
+		for _, accountID := range batch {
+			accountDetails = append(accountDetails, &guardduty.AccountDetail{AccountId: aws.String(accountID), Email: aws.String(emails[accountID])})
+		}
+
+		result, err := client.CreateMembers(&guardduty.CreateMembersInput{AccountDetails: accountDetails, DetectorId: aws.String(detectorID)})
+		if err != nil {
+			return nil, err
+		}
+
+		unprocessed := make(map[string]string)
+		for _, account := range result.UnprocessedAccounts {
+			unprocessed[aws.StringValue(account.AccountId)] = aws.StringValue(account.Result)
+		}
+		return unprocessed, nil
+	})
+
+	logMemberResults(results)
+	return results
+}
+
+// removeStaleGuardDutyMemberAccounts disassociates and deletes members that are no longer accounts in the AWS
+// Organization. Members listed as external accounts are left alone.
+func removeStaleGuardDutyMemberAccounts(client *guardduty.GuardDuty, detectorID string, memberAccounts []AccountWithEmail, externalAccountIDs []string) {
+	members, err := listGuardDutyMembers(client, detectorID)
 	if err != nil {
 		logrus.Error(err)
-	} else {
-		if len(result.UnprocessedAccounts) > 0 {
-			logrus.Error(result)
+		return
+	}
+
+	statuses := make(map[string]string)
+	for accountID, member := range members {
+		statuses[accountID] = aws.StringValue(member.RelationshipStatus)
+	}
+
+	organizationAccountIDs := make([]string, 0, len(memberAccounts))
+	for i := range memberAccounts {
+		organizationAccountIDs = append(organizationAccountIDs, memberAccounts[i].AccountID)
+	}
+
+	stale := staleMemberAccountIDs(statuses, organizationAccountIDs, externalAccountIDs)
+	if len(stale) == 0 {
+		logrus.Info("    no stale member accounts found")
+		return
+	}
+
+	for _, batch := range batchAccountIDs(stale, createMembersBatchSize) {
+		logrus.Infof("    removing stale member accounts %v", batch)
+
+		_, err := client.DisassociateMembers(&guardduty.DisassociateMembersInput{AccountIds: aws.StringSlice(batch), DetectorId: aws.String(detectorID)})
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		_, err = client.DeleteMembers(&guardduty.DeleteMembersInput{AccountIds: aws.StringSlice(batch), DetectorId: aws.String(detectorID)})
+		if err != nil {
+			logrus.Error(err)
 		}
 	}
 }
@@ -109,6 +172,40 @@ func getDetectorIDForRegion(client *guardduty.GuardDuty) string {
 	return *detectors.DetectorIds[0]
 }
 
+// guardDutyAdminDetector holds a client for the GuardDuty Administrator Account in a single region along with the ID of
+// the Administrator Account's detector in that region
+type guardDutyAdminDetector struct {
+	region     string
+	client     *guardduty.GuardDuty
+	detectorID string
+}
+
+// getGuardDutyAdminDetectors returns the GuardDuty Administrator Account's detector for every enabled region. Regions
+// without a detector are logged and skipped.
+func getGuardDutyAdminDetectors(region string, administratorAccountRole string) []guardDutyAdminDetector {
+	enabledRegions := GetEnabledRegions(region, administratorAccountRole, false)
+	return getGuardDutyAdminDetectorsInRegions(enabledRegions, administratorAccountRole)
+}
+
+// getGuardDutyAdminDetectorsInRegions returns the GuardDuty Administrator Account's detector for each of the regions
+func getGuardDutyAdminDetectorsInRegions(regions []string, administratorAccountRole string) []guardDutyAdminDetector {
+	detectors := make([]guardDutyAdminDetector, 0)
+	for r := range regions {
+		currentRegion := regions[r]
+		client := getGuardDutyClient(currentRegion, administratorAccountRole)
+
+		detectorID := getDetectorIDForRegion(client)
+		if detectorID == "" {
+			logrus.Warnf("  skipping region %s, no GuardDuty detector found", currentRegion)
+			continue
+		}
+
+		detectors = append(detectors, guardDutyAdminDetector{region: currentRegion, client: client, detectorID: detectorID})
+	}
+
+	return detectors
+}
+
 func enableGuardDutyAutoEnable(client *guardduty.GuardDuty, autoEnableS3Protection bool) {
 	logrus.Info("    Enabling GuardDuty Auto-Enable for new AWS Organization Member Accounts")
 	detector := getDetectorIDForRegion(client)
@@ -127,7 +224,16 @@ func enableGuardDutyAutoEnable(client *guardduty.GuardDuty, autoEnableS3Protecti
 }
 
 // EnableGuardDutyAdministratorAccount enables the GuardDuty Administrator account within the AWS Organization
-func EnableGuardDutyAdministratorAccount(region string, administratorAccountRole string, rootRole string, autoEnableS3Protection bool) error {
+func EnableGuardDutyAdministratorAccount(region string, administratorAccountRole string, rootRole string, autoEnableS3Protection bool, settings GuardDutyDetectorSettings, reconcile bool, externalAccountsFile string) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	externalAccountIDs, err := readExternalAccountIDs(externalAccountsFile)
+	if err != nil {
+		return err
+	}
+
 	rootSession := GetSession()
 	rootAccountID := GetAccountIDWithRole(rootSession, rootRole)
 
@@ -143,6 +249,8 @@ func EnableGuardDutyAdministratorAccount(region string, administratorAccountRole
 	memberAccounts := ListMemberAccountIDsWithEmails(rootRole)
 	logGuardDutyMemberAccounts(memberAccounts)
 
+	rows := make([][]string, 0)
+	failed := 0
 	for r := range enabledRegions {
 		currentRegion := enabledRegions[r]
 		logrus.Infof("  Processing region %s", currentRegion)
@@ -156,15 +264,30 @@ func EnableGuardDutyAdministratorAccount(region string, administratorAccountRole
 			enableGuardDutyAdminAccount(rootAccountClient, adminAccountID)
 			detectorID = getDetectorIDForRegion(adminAccountClient)
 
-			enableGuardDutyInManagementAccount(rootAccountClient)
+			enableGuardDutyInManagementAccount(rootAccountClient, settings)
 
 		} else {
 			logrus.Infof("    Account %s is already set as AWS GuardDuty Administrator Account, skipping configuration", adminAccountID)
 		}
 		enableGuardDutyAutoEnable(adminAccountClient, autoEnableS3Protection)
-		addGuardDutyMemberAccounts(adminAccountClient, detectorID, memberAccounts, adminAccountID)
+		results := addGuardDutyMemberAccounts(adminAccountClient, detectorID, memberAccounts, adminAccountID)
+		regionRows, regionFailed := memberResultRows(currentRegion, results)
+		rows = append(rows, regionRows...)
+		failed += regionFailed
+
+		if reconcile {
+			removeStaleGuardDutyMemberAccounts(adminAccountClient, detectorID, memberAccounts, externalAccountIDs)
+		}
+
+		if err := applyGuardDutyDetectorSettings(adminAccountClient, currentRegion, adminAccountID, detectorID, settings, false); err != nil {
+			logrus.Error(err)
+		}
+		rootIsMember := isGuardDutyMember(adminAccountClient, detectorID, rootAccountID)
+		if err := applyGuardDutyDetectorSettings(rootAccountClient, currentRegion, rootAccountID, getDetectorIDForRegion(rootAccountClient), settings, rootIsMember); err != nil {
+			logrus.Error(err)
+		}
 	}
 	logrus.Infof("Organization-wide AWS GuardDuty complete")
 
-	return nil
+	return writeMemberResults(rows, failed)
 }
@@ -0,0 +1,154 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+func coverageFilterCriteria(accountIDs []string) *guardduty.CoverageFilterCriteria {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	return &guardduty.CoverageFilterCriteria{
+		FilterCriterion: []*guardduty.CoverageFilterCriterion{
+			{
+				CriterionKey:    aws.String(guardduty.CoverageFilterCriterionKeyAccountId),
+				FilterCondition: &guardduty.CoverageFilterCondition{Equals: aws.StringSlice(accountIDs)},
+			},
+		},
+	}
+}
+
+// getCoverageCounts returns the number of healthy and unhealthy resources covered by runtime monitoring
+func (detector guardDutyAdminDetector) getCoverageCounts(accountIDs []string) (int64, int64, error) {
+	result, err := detector.client.GetCoverageStatistics(&guardduty.GetCoverageStatisticsInput{
+		DetectorId:     aws.String(detector.detectorID),
+		FilterCriteria: coverageFilterCriteria(accountIDs),
+		StatisticsType: aws.StringSlice([]string{guardduty.CoverageStatisticsTypeCountByCoverageStatus}),
+	})
+	if err != nil || result.CoverageStatistics == nil {
+		return 0, 0, err
+	}
+
+	counts := result.CoverageStatistics.CountByCoverageStatus
+	return aws.Int64Value(counts[guardduty.CoverageStatusHealthy]), aws.Int64Value(counts[guardduty.CoverageStatusUnhealthy]), nil
+}
+
+func (detector guardDutyAdminDetector) listCoverage(accountIDs []string) ([]*guardduty.CoverageResource, error) {
+	resources := make([]*guardduty.CoverageResource, 0)
+	err := detector.client.ListCoveragePages(
+		&guardduty.ListCoverageInput{DetectorId: aws.String(detector.detectorID), FilterCriteria: coverageFilterCriteria(accountIDs)},
+		func(page *guardduty.ListCoverageOutput, lastPage bool) bool {
+			resources = append(resources, page.Resources...)
+			return true
+		})
+
+	return resources, err
+}
+
+// coverageResourceName returns the cluster name or instance ID of a covered resource
+func coverageResourceName(resource *guardduty.CoverageResource) (string, string) {
+	details := resource.ResourceDetails
+	if details == nil {
+		return "", aws.StringValue(resource.ResourceId)
+	}
+
+	resourceType := aws.StringValue(details.ResourceType)
+	switch {
+	case details.EksClusterDetails != nil:
+		return resourceType, aws.StringValue(details.EksClusterDetails.ClusterName)
+	case details.EcsClusterDetails != nil:
+		return resourceType, aws.StringValue(details.EcsClusterDetails.ClusterName)
+	case details.Ec2InstanceDetails != nil:
+		return resourceType, aws.StringValue(details.Ec2InstanceDetails.InstanceId)
+	default:
+		return resourceType, aws.StringValue(resource.ResourceId)
+	}
+}
+
+// ReportGuardDutyCoverage writes the runtime monitoring coverage status of every resource, and any issue preventing
+// coverage, from the GuardDuty Administrator Account's detector in every enabled region to stdout. When minCoverage is
+// greater than zero, an error is returned if the percentage of healthy resources is below it.
+func ReportGuardDutyCoverage(region string, administratorAccountRole string, accountIDs []string, minCoverage float64, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	logrus.Info("Querying AWS GuardDuty runtime monitoring coverage")
+
+	var totalHealthy, totalUnhealthy int64
+	rows := make([][]string, 0)
+
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		healthy, unhealthy, err := detector.getCoverageCounts(accountIDs)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		logrus.Infof("  region %s: %d healthy, %d unhealthy", detector.region, healthy, unhealthy)
+		totalHealthy += healthy
+		totalUnhealthy += unhealthy
+
+		resources, err := detector.listCoverage(accountIDs)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		for _, resource := range resources {
+			resourceType, name := coverageResourceName(resource)
+			rows = append(rows, []string{
+				detector.region,
+				aws.StringValue(resource.AccountId),
+				resourceType,
+				name,
+				aws.StringValue(resource.CoverageStatus),
+				aws.StringValue(resource.Issue),
+			})
+		}
+	}
+
+	headers := []string{"REGION", "ACCOUNT", "RESOURCE_TYPE", "RESOURCE", "STATUS", "ISSUE"}
+	if err := output.Rows(os.Stdout, format, headers, rows); err != nil {
+		return err
+	}
+
+	total := totalHealthy + totalUnhealthy
+	if total == 0 {
+		logrus.Info("No resources are covered by runtime monitoring")
+		return nil
+	}
+
+	coverage := float64(totalHealthy) / float64(total) * 100
+	logrus.Infof("Runtime monitoring coverage is %.1f%% (%d of %d resources healthy)", coverage, totalHealthy, total)
+
+	if minCoverage > 0 && coverage < minCoverage {
+		return fmt.Errorf("runtime monitoring coverage of %.1f%% is below the minimum of %.1f%%", coverage, minCoverage)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/sirupsen/logrus"
+)
+
+// GuardDutyDetectorSettings are the settings applied to every GuardDuty detector in the AWS Organization. Empty values
+// are left unmanaged.
+type GuardDutyDetectorSettings struct {
+	FindingPublishingFrequency string
+	Tags                       map[string]string
+}
+
+func (settings GuardDutyDetectorSettings) validate() error {
+	if settings.FindingPublishingFrequency == "" {
+		return nil
+	}
+
+	for _, frequency := range guardduty.FindingPublishingFrequency_Values() {
+		if settings.FindingPublishingFrequency == frequency {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not a valid finding publishing frequency, must be one of %v", settings.FindingPublishingFrequency, guardduty.FindingPublishingFrequency_Values())
+}
+
+func (settings GuardDutyDetectorSettings) isEmpty() bool {
+	return settings.FindingPublishingFrequency == "" && len(settings.Tags) == 0
+}
+
+// drift describes how the detector differs from the settings. Tags on the detector that aren't in the settings are
+// not considered drift, and neither is the finding publishing frequency of member accounts, which they inherit from
+// the Administrator Account.
+func (settings GuardDutyDetectorSettings) drift(detector *guardduty.GetDetectorOutput, isMember bool) []string {
+	differences := make([]string, 0)
+
+	currentFrequency := aws.StringValue(detector.FindingPublishingFrequency)
+	if !isMember && settings.FindingPublishingFrequency != "" && settings.FindingPublishingFrequency != currentFrequency {
+		differences = append(differences, fmt.Sprintf("frequency %s (want %s)", currentFrequency, settings.FindingPublishingFrequency))
+	}
+
+	keys := make([]string, 0, len(settings.Tags))
+	for key := range settings.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		current, found := detector.Tags[key]
+		if !found {
+			differences = append(differences, fmt.Sprintf("tag %s missing", key))
+		} else if aws.StringValue(current) != settings.Tags[key] {
+			differences = append(differences, fmt.Sprintf("tag %s=%s (want %s)", key, aws.StringValue(current), settings.Tags[key]))
+		}
+	}
+
+	return differences
+}
+
+func formatGuardDutyDrift(differences []string) string {
+	if len(differences) == 0 {
+		return "none"
+	}
+	return strings.Join(differences, ", ")
+}
+
+func getGuardDutyDetectorArn(region string, accountID string, detectorID string) string {
+	return fmt.Sprintf("arn:aws:guardduty:%s:%s:detector/%s", region, accountID, detectorID)
+}
+
+// applyGuardDutyDetectorSettings updates the finding publishing frequency and tags of a detector when they have
+// drifted. Member accounts inherit the finding publishing frequency of the Administrator Account, so only their tags
+// are updated.
+func applyGuardDutyDetectorSettings(client *guardduty.GuardDuty, region string, accountID string, detectorID string, settings GuardDutyDetectorSettings, isMember bool) error {
+	if settings.isEmpty() || detectorID == "" {
+		return nil
+	}
+
+	detector, err := client.GetDetector(&guardduty.GetDetectorInput{DetectorId: aws.String(detectorID)})
+	if err != nil {
+		return err
+	}
+
+	differences := settings.drift(detector, isMember)
+	if len(differences) == 0 {
+		logrus.Infof("    detector %s in account %s is up to date", detectorID, accountID)
+		return nil
+	}
+
+	logrus.Infof("    updating detector %s in account %s: %s", detectorID, accountID, formatGuardDutyDrift(differences))
+
+	if !isMember && settings.FindingPublishingFrequency != "" && settings.FindingPublishingFrequency != aws.StringValue(detector.FindingPublishingFrequency) {
+		_, err := client.UpdateDetector(&guardduty.UpdateDetectorInput{
+			DetectorId:                 aws.String(detectorID),
+			FindingPublishingFrequency: aws.String(settings.FindingPublishingFrequency),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(settings.Tags) > 0 {
+		_, err := client.TagResource(&guardduty.TagResourceInput{
+			ResourceArn: aws.String(getGuardDutyDetectorArn(region, accountID, detectorID)),
+			Tags:        aws.StringMap(settings.Tags),
+		})
+		return err
+	}
+
+	return nil
+}
+
+// ConfigureGuardDutyDetectors applies the finding publishing frequency and tags to the detectors of the GuardDuty
+// Administrator Account, the AWS Management Account and, when memberRoleName is provided, every member account in
+// every enabled region
+func ConfigureGuardDutyDetectors(region string, administratorAccountRole string, rootRole string, memberRoleName string, settings GuardDutyDetectorSettings) error {
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	if settings.isEmpty() {
+		return fmt.Errorf("Either the finding publishing frequency or tags must be provided")
+	}
+
+	rootSession := GetSession()
+	rootAccountID := GetAccountIDWithRole(rootSession, rootRole)
+
+	adminAcctSession := GetSession()
+	adminAccountID := GetAccountIDWithRole(adminAcctSession, administratorAccountRole)
+
+	enabledRegions := GetEnabledRegions(region, rootRole, false)
+
+	memberAccounts := make([]AccountWithEmail, 0)
+	if memberRoleName != "" {
+		memberAccounts = ListMemberAccountIDsWithEmails(rootRole)
+	}
+
+	logrus.Info("Configuring AWS GuardDuty detectors")
+
+	for r := range enabledRegions {
+		currentRegion := enabledRegions[r]
+		logrus.Infof("  Processing region %s", currentRegion)
+
+		adminAccountClient := getGuardDutyClient(currentRegion, administratorAccountRole)
+		adminDetectorID := getDetectorIDForRegion(adminAccountClient)
+		err := applyGuardDutyDetectorSettings(adminAccountClient, currentRegion, adminAccountID, adminDetectorID, settings, false)
+		if err != nil {
+			logrus.Error(err)
+		}
+
+		rootAccountClient := getGuardDutyClient(currentRegion, rootRole)
+		rootIsMember := isGuardDutyMember(adminAccountClient, adminDetectorID, rootAccountID)
+		err = applyGuardDutyDetectorSettings(rootAccountClient, currentRegion, rootAccountID, getDetectorIDForRegion(rootAccountClient), settings, rootIsMember)
+		if err != nil {
+			logrus.Error(err)
+		}
+
+		for i := range memberAccounts {
+			accountID := memberAccounts[i].AccountID
+			if accountID == adminAccountID || accountID == rootAccountID {
+				continue
+			}
+
+			memberClient := getGuardDutyClient(currentRegion, GetMemberRoleArn(accountID, memberRoleName))
+			err = applyGuardDutyDetectorSettings(memberClient, currentRegion, accountID, getDetectorIDForRegion(memberClient), settings, true)
+			if err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	logrus.Info("AWS GuardDuty detector configuration complete")
+
+	return nil
+}
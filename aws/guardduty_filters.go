@@ -0,0 +1,315 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common"
+	"github.com/sirupsen/logrus"
+)
+
+// GuardDutyFilterCondition is a single condition of a GuardDuty filter's finding criteria
+type GuardDutyFilterCondition struct {
+	Equals             []string `yaml:"equals,omitempty"`
+	NotEquals          []string `yaml:"notEquals,omitempty"`
+	GreaterThan        *int64   `yaml:"greaterThan,omitempty"`
+	GreaterThanOrEqual *int64   `yaml:"greaterThanOrEqual,omitempty"`
+	LessThan           *int64   `yaml:"lessThan,omitempty"`
+	LessThanOrEqual    *int64   `yaml:"lessThanOrEqual,omitempty"`
+}
+
+// GuardDutyFilterDefinition describes a GuardDuty filter (suppression rule). The criteria are keyed by finding
+// attribute, e.g. `type` or `resource.instanceDetails.instanceId`.
+type GuardDutyFilterDefinition struct {
+	Name        string                              `yaml:"name"`
+	Description string                              `yaml:"description,omitempty"`
+	Action      string                              `yaml:"action"`
+	Rank        int64                               `yaml:"rank"`
+	Criteria    map[string]GuardDutyFilterCondition `yaml:"criteria"`
+}
+
+// GuardDutyFilterDefinitions is the format of the file read by SyncGuardDutyFilters
+type GuardDutyFilterDefinitions struct {
+	Filters []GuardDutyFilterDefinition `yaml:"filters"`
+}
+
+func (definition GuardDutyFilterDefinition) validate() error {
+	if definition.Name == "" {
+		return fmt.Errorf("filter name must be provided")
+	}
+
+	if definition.Action != guardduty.FilterActionArchive && definition.Action != guardduty.FilterActionNoop {
+		return fmt.Errorf("filter %s has invalid action %s, must be %s or %s", definition.Name, definition.Action, guardduty.FilterActionArchive, guardduty.FilterActionNoop)
+	}
+
+	if definition.Rank < 1 || definition.Rank > 100 {
+		return fmt.Errorf("filter %s has invalid rank %d, must be between 1 and 100", definition.Name, definition.Rank)
+	}
+
+	if len(definition.Criteria) == 0 {
+		return fmt.Errorf("filter %s must have at least one criterion", definition.Name)
+	}
+
+	return nil
+}
+
+func (definition GuardDutyFilterDefinition) findingCriteria() *guardduty.FindingCriteria {
+	criterion := make(map[string]*guardduty.Condition)
+	for attribute, condition := range definition.Criteria {
+		criterion[attribute] = &guardduty.Condition{
+			Equals:             aws.StringSlice(condition.Equals),
+			NotEquals:          aws.StringSlice(condition.NotEquals),
+			GreaterThan:        condition.GreaterThan,
+			GreaterThanOrEqual: condition.GreaterThanOrEqual,
+			LessThan:           condition.LessThan,
+			LessThanOrEqual:    condition.LessThanOrEqual,
+		}
+	}
+
+	return &guardduty.FindingCriteria{Criterion: criterion}
+}
+
+// normalized sorts the values of each condition so that definitions can be compared regardless of ordering
+func (definition GuardDutyFilterDefinition) normalized() GuardDutyFilterDefinition {
+	criteria := make(map[string]GuardDutyFilterCondition)
+	for attribute, condition := range definition.Criteria {
+		condition.Equals = sortedOrNil(condition.Equals)
+		condition.NotEquals = sortedOrNil(condition.NotEquals)
+		criteria[attribute] = condition
+	}
+	definition.Criteria = criteria
+
+	return definition
+}
+
+func sortedOrNil(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func firstNonNilInt64(values ...*int64) *int64 {
+	for _, value := range values {
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// guardDutyFilterDefinitionFromAPI converts an existing filter to a definition. GuardDuty may return conditions using
+// either the current or the deprecated operators, so both are considered.
+func guardDutyFilterDefinitionFromAPI(filter *guardduty.GetFilterOutput) GuardDutyFilterDefinition {
+	criteria := make(map[string]GuardDutyFilterCondition)
+	if filter.FindingCriteria != nil {
+		for attribute, condition := range filter.FindingCriteria.Criterion {
+			equals := condition.Equals
+			if len(equals) == 0 {
+				equals = condition.Eq
+			}
+			notEquals := condition.NotEquals
+			if len(notEquals) == 0 {
+				notEquals = condition.Neq
+			}
+
+			criteria[attribute] = GuardDutyFilterCondition{
+				Equals:             aws.StringValueSlice(equals),
+				NotEquals:          aws.StringValueSlice(notEquals),
+				GreaterThan:        firstNonNilInt64(condition.GreaterThan, condition.Gt),
+				GreaterThanOrEqual: firstNonNilInt64(condition.GreaterThanOrEqual, condition.Gte),
+				LessThan:           firstNonNilInt64(condition.LessThan, condition.Lt),
+				LessThanOrEqual:    firstNonNilInt64(condition.LessThanOrEqual, condition.Lte),
+			}
+		}
+	}
+
+	return GuardDutyFilterDefinition{
+		Name:        aws.StringValue(filter.Name),
+		Description: aws.StringValue(filter.Description),
+		Action:      aws.StringValue(filter.Action),
+		Rank:        aws.Int64Value(filter.Rank),
+		Criteria:    criteria,
+	}
+}
+
+func readGuardDutyFilterDefinitions(path string) ([]GuardDutyFilterDefinition, error) {
+	definitions := GuardDutyFilterDefinitions{}
+	if err := common.ReadYAMLFile(path, &definitions); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, definition := range definitions.Filters {
+		if err := definition.validate(); err != nil {
+			return nil, err
+		}
+		if names[definition.Name] {
+			return nil, fmt.Errorf("filter %s is defined more than once", definition.Name)
+		}
+		names[definition.Name] = true
+	}
+
+	return definitions.Filters, nil
+}
+
+func (detector guardDutyAdminDetector) getFilters() (map[string]GuardDutyFilterDefinition, error) {
+	names := make([]*string, 0)
+	err := detector.client.ListFiltersPages(
+		&guardduty.ListFiltersInput{DetectorId: aws.String(detector.detectorID)},
+		func(page *guardduty.ListFiltersOutput, lastPage bool) bool {
+			names = append(names, page.FilterNames...)
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make(map[string]GuardDutyFilterDefinition)
+	for _, name := range names {
+		filter, err := detector.client.GetFilter(&guardduty.GetFilterInput{DetectorId: aws.String(detector.detectorID), FilterName: name})
+		if err != nil {
+			return nil, err
+		}
+		filters[*name] = guardDutyFilterDefinitionFromAPI(filter)
+	}
+
+	return filters, nil
+}
+
+func (detector guardDutyAdminDetector) createFilter(definition GuardDutyFilterDefinition) error {
+	_, err := detector.client.CreateFilter(&guardduty.CreateFilterInput{
+		Action:          aws.String(definition.Action),
+		Description:     aws.String(definition.Description),
+		DetectorId:      aws.String(detector.detectorID),
+		FindingCriteria: definition.findingCriteria(),
+		Name:            aws.String(definition.Name),
+		Rank:            aws.Int64(definition.Rank),
+	})
+	return err
+}
+
+func (detector guardDutyAdminDetector) updateFilter(definition GuardDutyFilterDefinition) error {
+	_, err := detector.client.UpdateFilter(&guardduty.UpdateFilterInput{
+		Action:          aws.String(definition.Action),
+		Description:     aws.String(definition.Description),
+		DetectorId:      aws.String(detector.detectorID),
+		FilterName:      aws.String(definition.Name),
+		FindingCriteria: definition.findingCriteria(),
+		Rank:            aws.Int64(definition.Rank),
+	})
+	return err
+}
+
+func (detector guardDutyAdminDetector) deleteFilter(name string) error {
+	_, err := detector.client.DeleteFilter(&guardduty.DeleteFilterInput{
+		DetectorId: aws.String(detector.detectorID),
+		FilterName: aws.String(name),
+	})
+	return err
+}
+
+// syncFilters plans the changes needed to make the detector's filters match the definitions and, when apply is set,
+// makes them. Filters that aren't defined are only deleted when prune is set.
+func (detector guardDutyAdminDetector) syncFilters(definitions []GuardDutyFilterDefinition, prune bool, apply bool) error {
+	existing, err := detector.getFilters()
+	if err != nil {
+		return err
+	}
+
+	defined := make(map[string]bool)
+	for _, definition := range definitions {
+		defined[definition.Name] = true
+
+		var err error
+		current, found := existing[definition.Name]
+		switch {
+		case !found:
+			logrus.Infof("    + create filter %s (%s, rank %d)", definition.Name, definition.Action, definition.Rank)
+			if apply {
+				err = detector.createFilter(definition)
+			}
+		case !reflect.DeepEqual(current.normalized(), definition.normalized()):
+			logrus.Infof("    ~ update filter %s (%s, rank %d)", definition.Name, definition.Action, definition.Rank)
+			if apply {
+				err = detector.updateFilter(definition)
+			}
+		default:
+			logrus.Infof("    = filter %s is up to date", definition.Name)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	for name := range existing {
+		if defined[name] {
+			continue
+		}
+
+		if !prune {
+			logrus.Infof("    ! filter %s is not defined, run with --prune to delete it", name)
+			continue
+		}
+
+		logrus.Infof("    - delete filter %s", name)
+		if apply {
+			if err := detector.deleteFilter(name); err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SyncGuardDutyFilters makes the filters of the GuardDuty Administrator Account's detector in every enabled region
+// match the definitions in a YAML file. The planned changes are always shown and are only made when apply is set.
+func SyncGuardDutyFilters(region string, administratorAccountRole string, path string, prune bool, apply bool) error {
+	definitions, err := readGuardDutyFilterDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Syncing %d AWS GuardDuty filters from %s", len(definitions), path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		logrus.Infof("  Processing region %s", detector.region)
+
+		if err := detector.syncFilters(definitions, prune, apply); err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	logrus.Info("AWS GuardDuty filter sync complete")
+
+	return nil
+}
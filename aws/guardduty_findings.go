@@ -0,0 +1,317 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// FormatASFF outputs GuardDuty findings in a format resembling the AWS Security Finding Format
+const FormatASFF string = "asff"
+
+// GetFindings accepts at most 50 finding IDs per request
+const guardDutyGetFindingsBatchSize = 50
+
+// GuardDutyFindingsFilter contains the criteria used to select GuardDuty findings
+type GuardDutyFindingsFilter struct {
+	MinSeverity float64
+	Type        string
+	AccountIDs  []string
+	Start       time.Time
+	End         time.Time
+	Archived    bool
+}
+
+// findingCriteria converts the filter to GuardDuty finding criteria. Type globs and fractional severities can't be
+// expressed as criteria, so they are also checked by matches once the findings have been retrieved.
+func (filter GuardDutyFindingsFilter) findingCriteria() *guardduty.FindingCriteria {
+	criterion := map[string]*guardduty.Condition{
+		"service.archived": {Equals: aws.StringSlice([]string{strconv.FormatBool(filter.Archived)})},
+	}
+
+	if filter.MinSeverity > 0 {
+		criterion["severity"] = &guardduty.Condition{GreaterThanOrEqual: aws.Int64(int64(filter.MinSeverity))}
+	}
+
+	if filter.Type != "" && !common.IsGlob(filter.Type) {
+		criterion["type"] = &guardduty.Condition{Equals: aws.StringSlice([]string{filter.Type})}
+	}
+
+	if len(filter.AccountIDs) > 0 {
+		criterion["accountId"] = &guardduty.Condition{Equals: aws.StringSlice(filter.AccountIDs)}
+	}
+
+	if !filter.Start.IsZero() || !filter.End.IsZero() {
+		updatedAt := &guardduty.Condition{}
+		if !filter.Start.IsZero() {
+			updatedAt.GreaterThanOrEqual = aws.Int64(aws.TimeUnixMilli(filter.Start))
+		}
+		if !filter.End.IsZero() {
+			updatedAt.LessThanOrEqual = aws.Int64(aws.TimeUnixMilli(filter.End))
+		}
+		criterion["updatedAt"] = updatedAt
+	}
+
+	return &guardduty.FindingCriteria{Criterion: criterion}
+}
+
+func (filter GuardDutyFindingsFilter) matches(finding *guardduty.Finding) bool {
+	if aws.Float64Value(finding.Severity) < filter.MinSeverity {
+		return false
+	}
+
+	if filter.Type != "" && !common.MatchGlob(filter.Type, aws.StringValue(finding.Type)) {
+		return false
+	}
+
+	return true
+}
+
+func (detector guardDutyAdminDetector) listFindingIDs(filter GuardDutyFindingsFilter) ([]*string, error) {
+	findingIDs := make([]*string, 0)
+
+	err := detector.client.ListFindingsPages(
+		&guardduty.ListFindingsInput{DetectorId: aws.String(detector.detectorID), FindingCriteria: filter.findingCriteria()},
+		func(page *guardduty.ListFindingsOutput, lastPage bool) bool {
+			findingIDs = append(findingIDs, page.FindingIds...)
+			return true
+		})
+
+	return findingIDs, err
+}
+
+// findFindings returns all of the findings of the detector that match the filter
+func (detector guardDutyAdminDetector) findFindings(filter GuardDutyFindingsFilter) ([]*guardduty.Finding, error) {
+	findingIDs, err := detector.listFindingIDs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]*guardduty.Finding, 0)
+	for start := 0; start < len(findingIDs); start += guardDutyGetFindingsBatchSize {
+		end := start + guardDutyGetFindingsBatchSize
+		if end > len(findingIDs) {
+			end = len(findingIDs)
+		}
+
+		result, err := detector.client.GetFindings(&guardduty.GetFindingsInput{
+			DetectorId: aws.String(detector.detectorID),
+			FindingIds: findingIDs[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, finding := range result.Findings {
+			if filter.matches(finding) {
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// sortGuardDutyFindings sorts findings by severity, then by the time they were last updated, most recent first
+func sortGuardDutyFindings(findings []*guardduty.Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if aws.Float64Value(findings[i].Severity) != aws.Float64Value(findings[j].Severity) {
+			return aws.Float64Value(findings[i].Severity) > aws.Float64Value(findings[j].Severity)
+		}
+		return aws.StringValue(findings[i].UpdatedAt) > aws.StringValue(findings[j].UpdatedAt)
+	})
+}
+
+// guardDutySeverityLabel maps a GuardDuty severity to its label
+//
+// https://docs.aws.amazon.com/guardduty/latest/ug/guardduty_findings.html#guardduty_findings-severity
+func guardDutySeverityLabel(severity float64) string {
+	switch {
+	case severity >= 9:
+		return "CRITICAL"
+	case severity >= 7:
+		return "HIGH"
+	case severity >= 4:
+		return "MEDIUM"
+	default:
+		return "LOW"
+	}
+}
+
+func guardDutyFindingResourceType(finding *guardduty.Finding) string {
+	if finding.Resource == nil {
+		return ""
+	}
+	return aws.StringValue(finding.Resource.ResourceType)
+}
+
+func guardDutyFindingResourceID(finding *guardduty.Finding) string {
+	resource := finding.Resource
+	if resource == nil {
+		return ""
+	}
+
+	switch {
+	case resource.InstanceDetails != nil:
+		return aws.StringValue(resource.InstanceDetails.InstanceId)
+	case resource.AccessKeyDetails != nil:
+		return aws.StringValue(resource.AccessKeyDetails.AccessKeyId)
+	case len(resource.S3BucketDetails) > 0:
+		return aws.StringValue(resource.S3BucketDetails[0].Name)
+	default:
+		return ""
+	}
+}
+
+type asffSeverity struct {
+	Label      string
+	Normalized int
+	Product    float64
+}
+
+type asffResource struct {
+	Type   string
+	Id     string
+	Region string
+}
+
+// asffFinding is the subset of the AWS Security Finding Format that GuardDuty findings are mapped to
+//
+// https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-findings-format.html
+type asffFinding struct {
+	SchemaVersion string
+	Id            string
+	ProductArn    string
+	GeneratorId   string
+	AwsAccountId  string
+	Types         []string
+	CreatedAt     string
+	UpdatedAt     string
+	Severity      asffSeverity
+	Title         string
+	Description   string
+	Resources     []asffResource
+}
+
+func toASFF(finding *guardduty.Finding) asffFinding {
+	severity := aws.Float64Value(finding.Severity)
+	region := aws.StringValue(finding.Region)
+
+	return asffFinding{
+		SchemaVersion: "2018-10-08",
+		Id:            aws.StringValue(finding.Arn),
+		ProductArn:    fmt.Sprintf("arn:%s:securityhub:%s::product/aws/guardduty", aws.StringValue(finding.Partition), region),
+		GeneratorId:   aws.StringValue(finding.Type),
+		AwsAccountId:  aws.StringValue(finding.AccountId),
+		Types:         []string{aws.StringValue(finding.Type)},
+		CreatedAt:     aws.StringValue(finding.CreatedAt),
+		UpdatedAt:     aws.StringValue(finding.UpdatedAt),
+		Severity: asffSeverity{
+			Label:      guardDutySeverityLabel(severity),
+			Normalized: int(severity * 10),
+			Product:    severity,
+		},
+		Title:       aws.StringValue(finding.Title),
+		Description: aws.StringValue(finding.Description),
+		Resources: []asffResource{
+			{
+				Type:   guardDutyFindingResourceType(finding),
+				Id:     guardDutyFindingResourceID(finding),
+				Region: region,
+			},
+		},
+	}
+}
+
+func writeGuardDutyFindings(findings []*guardduty.Finding, format string) error {
+	switch format {
+	case output.FormatJSON:
+		return output.JSON(os.Stdout, findings)
+	case FormatASFF:
+		asff := make([]asffFinding, 0, len(findings))
+		for _, finding := range findings {
+			asff = append(asff, toASFF(finding))
+		}
+		return output.JSON(os.Stdout, asff)
+	}
+
+	headers := []string{"SEVERITY", "REGION", "ACCOUNT", "TYPE", "RESOURCE", "UPDATED", "TITLE", "ID"}
+	rows := make([][]string, 0, len(findings))
+	for _, finding := range findings {
+		severity := aws.Float64Value(finding.Severity)
+		rows = append(rows, []string{
+			fmt.Sprintf("%s (%.1f)", guardDutySeverityLabel(severity), severity),
+			aws.StringValue(finding.Region),
+			aws.StringValue(finding.AccountId),
+			aws.StringValue(finding.Type),
+			guardDutyFindingResourceID(finding),
+			aws.StringValue(finding.UpdatedAt),
+			aws.StringValue(finding.Title),
+			aws.StringValue(finding.Id),
+		})
+	}
+
+	if format == output.FormatCSV {
+		return output.CSV(os.Stdout, headers, rows)
+	}
+	return output.Table(os.Stdout, headers, rows)
+}
+
+func validateGuardDutyFindingsFormat(format string) error {
+	switch format {
+	case output.FormatTable, output.FormatJSON, output.FormatCSV, FormatASFF:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid output format, must be one of %s, %s, %s or %s", format, output.FormatTable, output.FormatJSON, output.FormatCSV, FormatASFF)
+}
+
+// ExportGuardDutyFindings queries the GuardDuty Administrator Account's detector in every enabled region for findings
+// matching the filter and writes them to stdout, sorted by severity and then by time
+func ExportGuardDutyFindings(region string, administratorAccountRole string, filter GuardDutyFindingsFilter, format string) error {
+	if err := validateGuardDutyFindingsFormat(format); err != nil {
+		return err
+	}
+
+	logrus.Info("Querying AWS GuardDuty findings")
+
+	findings := make([]*guardduty.Finding, 0)
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		regionFindings, err := detector.findFindings(filter)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		logrus.Infof("  found %d findings in region %s", len(regionFindings), detector.region)
+		findings = append(findings, regionFindings...)
+	}
+
+	sortGuardDutyFindings(findings)
+
+	return writeGuardDutyFindings(findings, format)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"math"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common"
+	"github.com/sirupsen/logrus"
+)
+
+// ArchiveFindings and UnarchiveFindings accept at most 50 finding IDs per request
+const guardDutyArchiveFindingsBatchSize = 50
+
+// isCriteriaOnly reports whether the filter can be evaluated entirely by GuardDuty, in which case the matching finding
+// IDs can be used without retrieving the findings
+func (filter GuardDutyFindingsFilter) isCriteriaOnly() bool {
+	return !common.IsGlob(filter.Type) && filter.MinSeverity == math.Trunc(filter.MinSeverity)
+}
+
+func (detector guardDutyAdminDetector) findFindingIDs(filter GuardDutyFindingsFilter) ([]*string, error) {
+	if filter.isCriteriaOnly() {
+		return detector.listFindingIDs(filter)
+	}
+
+	findings, err := detector.findFindings(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	findingIDs := make([]*string, 0, len(findings))
+	for _, finding := range findings {
+		findingIDs = append(findingIDs, finding.Id)
+	}
+
+	return findingIDs, nil
+}
+
+func (detector guardDutyAdminDetector) setFindingsArchived(findingIDs []*string, archive bool) error {
+	for start := 0; start < len(findingIDs); start += guardDutyArchiveFindingsBatchSize {
+		end := start + guardDutyArchiveFindingsBatchSize
+		if end > len(findingIDs) {
+			end = len(findingIDs)
+		}
+
+		var err error
+		if archive {
+			_, err = detector.client.ArchiveFindings(&guardduty.ArchiveFindingsInput{
+				DetectorId: aws.String(detector.detectorID),
+				FindingIds: findingIDs[start:end],
+			})
+		} else {
+			_, err = detector.client.UnarchiveFindings(&guardduty.UnarchiveFindingsInput{
+				DetectorId: aws.String(detector.detectorID),
+				FindingIds: findingIDs[start:end],
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetGuardDutyFindingsArchived archives, or unarchives, the findings matching the filter on the GuardDuty
+// Administrator Account's detector in every enabled region. Archiving selects current findings and unarchiving selects
+// archived findings, regardless of the filter's archived setting. The matching findings are only counted unless apply
+// is set.
+func SetGuardDutyFindingsArchived(region string, administratorAccountRole string, filter GuardDutyFindingsFilter, archive bool, apply bool) error {
+	filter.Archived = !archive
+
+	action := "Archiving"
+	if !archive {
+		action = "Unarchiving"
+	}
+
+	logrus.Infof("%s AWS GuardDuty findings", action)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	total := 0
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		findingIDs, err := detector.findFindingIDs(filter)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		logrus.Infof("  found %d matching findings in region %s", len(findingIDs), detector.region)
+		total += len(findingIDs)
+
+		if apply && len(findingIDs) > 0 {
+			if err := detector.setFindingsArchived(findingIDs, archive); err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	logrus.Infof("%s AWS GuardDuty findings complete, %d findings matched", action, total)
+
+	return nil
+}
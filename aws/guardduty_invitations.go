@@ -0,0 +1,199 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common"
+	"github.com/sirupsen/logrus"
+)
+
+// guardDutyReinvitableStatuses are the relationship statuses of members that need to be (re)invited
+var guardDutyReinvitableStatuses = map[string]bool{"Created": true, "Removed": true, "Resigned": true}
+
+// GuardDutyExternalAccount is an AWS Account outside of the AWS Organization that is invited to become a GuardDuty
+// member. Role is assumed in the account to accept the invitation.
+type GuardDutyExternalAccount struct {
+	AccountID string `yaml:"accountId"`
+	Email     string `yaml:"email"`
+	Role      string `yaml:"role"`
+}
+
+// GuardDutyExternalAccounts is the format of the file read by InviteGuardDutyMemberAccounts
+type GuardDutyExternalAccounts struct {
+	Accounts []GuardDutyExternalAccount `yaml:"accounts"`
+}
+
+func readGuardDutyExternalAccounts(path string) ([]GuardDutyExternalAccount, error) {
+	accounts := GuardDutyExternalAccounts{}
+	if err := common.ReadYAMLFile(path, &accounts); err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts.Accounts {
+		if account.AccountID == "" || account.Email == "" || account.Role == "" {
+			return nil, fmt.Errorf("the accountId, email and role of each account must be provided")
+		}
+	}
+
+	return accounts.Accounts, nil
+}
+
+// inviteGuardDutyMemberAccounts adds the external accounts as members of the Administrator Account's detector and
+// invites the ones that haven't been invited yet
+func inviteGuardDutyMemberAccounts(client *guardduty.GuardDuty, detectorID string, accounts []GuardDutyExternalAccount, message string) error {
+	members, err := listGuardDutyMembers(client, detectorID)
+	if err != nil {
+		return err
+	}
+
+	accountDetails := make([]*guardduty.AccountDetail, 0)
+	toInvite := make([]*string, 0)
+	for i := range accounts {
+		member, found := members[accounts[i].AccountID]
+		if !found {
+			accountDetails = append(accountDetails, &guardduty.AccountDetail{AccountId: aws.String(accounts[i].AccountID), Email: aws.String(accounts[i].Email)})
+		}
+
+		if !found || guardDutyReinvitableStatuses[aws.StringValue(member.RelationshipStatus)] {
+			toInvite = append(toInvite, aws.String(accounts[i].AccountID))
+		} else {
+			logrus.Infof("    account %s is already a member, relationship status %s", accounts[i].AccountID, aws.StringValue(member.RelationshipStatus))
+		}
+	}
+
+	if len(accountDetails) > 0 {
+		result, err := client.CreateMembers(&guardduty.CreateMembersInput{AccountDetails: accountDetails, DetectorId: aws.String(detectorID)})
+		if err != nil {
+			return err
+		}
+		logUnprocessedGuardDutyAccounts(result.UnprocessedAccounts)
+	}
+
+	if len(toInvite) == 0 {
+		return nil
+	}
+
+	input := guardduty.InviteMembersInput{AccountIds: toInvite, DetectorId: aws.String(detectorID)}
+	if message != "" {
+		input.Message = aws.String(message)
+	}
+
+	logrus.Infof("    inviting %d accounts", len(toInvite))
+	result, err := client.InviteMembers(&input)
+	if err != nil {
+		return err
+	}
+	logUnprocessedGuardDutyAccounts(result.UnprocessedAccounts)
+
+	return nil
+}
+
+func logUnprocessedGuardDutyAccounts(unprocessed []*guardduty.UnprocessedAccount) {
+	for _, account := range unprocessed {
+		logrus.Errorf("    account %s was not processed: %s", aws.StringValue(account.AccountId), aws.StringValue(account.Result))
+	}
+}
+
+// acceptGuardDutyAdministratorInvitation accepts the pending invitation from the Administrator Account, creating a
+// detector in the member account first if it doesn't have one
+func acceptGuardDutyAdministratorInvitation(client *guardduty.GuardDuty, administratorAcctID string) error {
+	var invitation *guardduty.Invitation
+	err := client.ListInvitationsPages(&guardduty.ListInvitationsInput{}, func(page *guardduty.ListInvitationsOutput, lastPage bool) bool {
+		for _, current := range page.Invitations {
+			if aws.StringValue(current.AccountId) == administratorAcctID {
+				invitation = current
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if invitation == nil {
+		logrus.Info("      no pending invitation found")
+		return nil
+	}
+
+	detectors, err := client.ListDetectors(&guardduty.ListDetectorsInput{})
+	if err != nil {
+		return err
+	}
+
+	var detectorID *string
+	if len(detectors.DetectorIds) > 0 {
+		detectorID = detectors.DetectorIds[0]
+	} else {
+		logrus.Info("      creating detector")
+		result, err := client.CreateDetector(&guardduty.CreateDetectorInput{Enable: aws.Bool(true)})
+		if err != nil {
+			return err
+		}
+		detectorID = result.DetectorId
+	}
+
+	logrus.Infof("      accepting invitation %s", aws.StringValue(invitation.InvitationId))
+	_, err = client.AcceptAdministratorInvitation(&guardduty.AcceptAdministratorInvitationInput{
+		AdministratorId: aws.String(administratorAcctID),
+		DetectorId:      detectorID,
+		InvitationId:    invitation.InvitationId,
+	})
+
+	return err
+}
+
+// InviteGuardDutyMemberAccounts invites AWS Accounts outside of the AWS Organization to become members of the GuardDuty
+// Administrator Account in every enabled region, then assumes a role in each account to accept the invitation
+func InviteGuardDutyMemberAccounts(region string, administratorAccountRole string, path string, message string) error {
+	accounts, err := readGuardDutyExternalAccounts(path)
+	if err != nil {
+		return err
+	}
+
+	adminAcctSession := GetSession()
+	adminAccountID := GetAccountIDWithRole(adminAcctSession, administratorAccountRole)
+
+	logrus.Infof("Inviting %d external accounts to AWS GuardDuty Administrator Account %s", len(accounts), adminAccountID)
+
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		logrus.Infof("  Processing region %s", detector.region)
+
+		if err := inviteGuardDutyMemberAccounts(detector.client, detector.detectorID, accounts, message); err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		for i := range accounts {
+			logrus.Infof("    accepting invitation in account %s", accounts[i].AccountID)
+
+			memberClient := getGuardDutyClient(detector.region, accounts[i].Role)
+			if err := acceptGuardDutyAdministratorInvitation(memberClient, adminAccountID); err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	logrus.Info("Inviting external accounts to AWS GuardDuty complete")
+
+	return nil
+}
@@ -0,0 +1,379 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// GuardDutyMalwareProtectionPlanDefinition describes a GuardDuty Malware Protection plan for an S3 bucket. The plan is
+// managed from the account and region of the bucket, using the credentials of AccountRole when provided, or the current
+// credentials otherwise. Role is the IAM role GuardDuty uses to scan the bucket.
+type GuardDutyMalwareProtectionPlanDefinition struct {
+	Bucket      string   `yaml:"bucket"`
+	Region      string   `yaml:"region"`
+	AccountRole string   `yaml:"accountRole,omitempty"`
+	Role        string   `yaml:"role"`
+	Prefixes    []string `yaml:"prefixes,omitempty"`
+	Tagging     bool     `yaml:"tagging"`
+}
+
+// GuardDutyMalwareProtectionPlanDefinitions is the format of the file read by the Malware Protection commands
+type GuardDutyMalwareProtectionPlanDefinitions struct {
+	Plans []GuardDutyMalwareProtectionPlanDefinition `yaml:"plans"`
+}
+
+func (definition GuardDutyMalwareProtectionPlanDefinition) validate() error {
+	if definition.Bucket == "" || definition.Region == "" || definition.Role == "" {
+		return fmt.Errorf("the bucket, region and role of each plan must be provided")
+	}
+	return nil
+}
+
+func (definition GuardDutyMalwareProtectionPlanDefinition) taggingStatus() string {
+	if definition.Tagging {
+		return guardduty.MalwareProtectionPlanTaggingActionStatusEnabled
+	}
+	return guardduty.MalwareProtectionPlanTaggingActionStatusDisabled
+}
+
+func (definition GuardDutyMalwareProtectionPlanDefinition) actions() *guardduty.MalwareProtectionPlanActions {
+	return &guardduty.MalwareProtectionPlanActions{
+		Tagging: &guardduty.MalwareProtectionPlanTaggingAction{Status: aws.String(definition.taggingStatus())},
+	}
+}
+
+// malwareProtectionPlan is an existing plan for an S3 bucket
+type malwareProtectionPlan struct {
+	id            string
+	bucket        string
+	role          string
+	prefixes      []string
+	taggingStatus string
+	status        string
+	statusReasons string
+}
+
+func (plan malwareProtectionPlan) differsFrom(definition GuardDutyMalwareProtectionPlanDefinition) bool {
+	return plan.role != definition.Role ||
+		plan.taggingStatus != definition.taggingStatus() ||
+		!reflect.DeepEqual(sortedOrNil(plan.prefixes), sortedOrNil(definition.Prefixes))
+}
+
+// malwareProtectionScope is an account and region in which Malware Protection plans are managed
+type malwareProtectionScope struct {
+	accountRole string
+	region      string
+}
+
+func (scope malwareProtectionScope) String() string {
+	if scope.accountRole == "" {
+		return fmt.Sprintf("region %s", scope.region)
+	}
+	return fmt.Sprintf("%s in region %s", scope.accountRole, scope.region)
+}
+
+func (scope malwareProtectionScope) client() *guardduty.GuardDuty {
+	if scope.accountRole == "" {
+		return guardduty.New(GetSession(), &aws.Config{Region: aws.String(scope.region)})
+	}
+	return getGuardDutyClient(scope.region, scope.accountRole)
+}
+
+// groupMalwareProtectionPlans groups the definitions by the account and region they are managed in, preserving the order
+// in which each scope first appears
+func groupMalwareProtectionPlans(definitions []GuardDutyMalwareProtectionPlanDefinition) ([]malwareProtectionScope, map[malwareProtectionScope][]GuardDutyMalwareProtectionPlanDefinition) {
+	scopes := make([]malwareProtectionScope, 0)
+	grouped := make(map[malwareProtectionScope][]GuardDutyMalwareProtectionPlanDefinition)
+	for _, definition := range definitions {
+		scope := malwareProtectionScope{accountRole: definition.AccountRole, region: definition.Region}
+		if _, found := grouped[scope]; !found {
+			scopes = append(scopes, scope)
+		}
+		grouped[scope] = append(grouped[scope], definition)
+	}
+
+	return scopes, grouped
+}
+
+// listMalwareProtectionPlans returns the S3 Malware Protection plans in the client's account and region, keyed by bucket
+func listMalwareProtectionPlans(client *guardduty.GuardDuty) (map[string]malwareProtectionPlan, error) {
+	ids := make([]*string, 0)
+	input := &guardduty.ListMalwareProtectionPlansInput{}
+	for {
+		result, err := client.ListMalwareProtectionPlans(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, summary := range result.MalwareProtectionPlans {
+			ids = append(ids, summary.MalwareProtectionPlanId)
+		}
+
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	plans := make(map[string]malwareProtectionPlan)
+	for _, id := range ids {
+		result, err := client.GetMalwareProtectionPlan(&guardduty.GetMalwareProtectionPlanInput{MalwareProtectionPlanId: id})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.ProtectedResource == nil || result.ProtectedResource.S3Bucket == nil {
+			continue
+		}
+
+		reasons := make([]string, 0)
+		for _, reason := range result.StatusReasons {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", aws.StringValue(reason.Code), aws.StringValue(reason.Message)))
+		}
+
+		plan := malwareProtectionPlan{
+			id:            *id,
+			bucket:        aws.StringValue(result.ProtectedResource.S3Bucket.BucketName),
+			role:          aws.StringValue(result.Role),
+			prefixes:      aws.StringValueSlice(result.ProtectedResource.S3Bucket.ObjectPrefixes),
+			status:        aws.StringValue(result.Status),
+			statusReasons: strings.Join(reasons, "; "),
+		}
+		if result.Actions != nil && result.Actions.Tagging != nil {
+			plan.taggingStatus = aws.StringValue(result.Actions.Tagging.Status)
+		}
+
+		plans[plan.bucket] = plan
+	}
+
+	return plans, nil
+}
+
+func createMalwareProtectionPlan(client *guardduty.GuardDuty, definition GuardDutyMalwareProtectionPlanDefinition) error {
+	_, err := client.CreateMalwareProtectionPlan(&guardduty.CreateMalwareProtectionPlanInput{
+		Actions: definition.actions(),
+		ProtectedResource: &guardduty.CreateProtectedResource{
+			S3Bucket: &guardduty.CreateS3BucketResource{
+				BucketName:     aws.String(definition.Bucket),
+				ObjectPrefixes: aws.StringSlice(definition.Prefixes),
+			},
+		},
+		Role: aws.String(definition.Role),
+	})
+	return err
+}
+
+func updateMalwareProtectionPlan(client *guardduty.GuardDuty, id string, definition GuardDutyMalwareProtectionPlanDefinition) error {
+	_, err := client.UpdateMalwareProtectionPlan(&guardduty.UpdateMalwareProtectionPlanInput{
+		Actions:                 definition.actions(),
+		MalwareProtectionPlanId: aws.String(id),
+		ProtectedResource: &guardduty.UpdateProtectedResource{
+			S3Bucket: &guardduty.UpdateS3BucketResource{ObjectPrefixes: aws.StringSlice(definition.Prefixes)},
+		},
+		Role: aws.String(definition.Role),
+	})
+	return err
+}
+
+func deleteMalwareProtectionPlan(client *guardduty.GuardDuty, id string) error {
+	_, err := client.DeleteMalwareProtectionPlan(&guardduty.DeleteMalwareProtectionPlanInput{MalwareProtectionPlanId: aws.String(id)})
+	return err
+}
+
+func readGuardDutyMalwareProtectionPlanDefinitions(path string) ([]GuardDutyMalwareProtectionPlanDefinition, error) {
+	definitions := GuardDutyMalwareProtectionPlanDefinitions{}
+	if err := common.ReadYAMLFile(path, &definitions); err != nil {
+		return nil, err
+	}
+
+	for _, definition := range definitions.Plans {
+		if err := definition.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return definitions.Plans, nil
+}
+
+// SyncGuardDutyMalwareProtectionPlans creates and updates the GuardDuty Malware Protection plans for the S3 buckets in a
+// YAML file, in the account and region of each bucket. When prune is set, plans for buckets that aren't in the file
+// are deleted from the accounts and regions that appear in the file. The planned changes are always shown and are only
+// made when apply is set.
+func SyncGuardDutyMalwareProtectionPlans(path string, prune bool, apply bool) error {
+	definitions, err := readGuardDutyMalwareProtectionPlanDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Syncing %d AWS GuardDuty Malware Protection plans from %s", len(definitions), path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	scopes, grouped := groupMalwareProtectionPlans(definitions)
+	for _, scope := range scopes {
+		logrus.Infof("  Processing %s", scope)
+
+		client := scope.client()
+		existing, err := listMalwareProtectionPlans(client)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		defined := make(map[string]bool)
+		for _, definition := range grouped[scope] {
+			defined[definition.Bucket] = true
+
+			var err error
+			current, found := existing[definition.Bucket]
+			switch {
+			case !found:
+				logrus.Infof("    + create plan for bucket %s", definition.Bucket)
+				if apply {
+					err = createMalwareProtectionPlan(client, definition)
+				}
+			case current.differsFrom(definition):
+				logrus.Infof("    ~ update plan %s for bucket %s", current.id, definition.Bucket)
+				if apply {
+					err = updateMalwareProtectionPlan(client, current.id, definition)
+				}
+			default:
+				logrus.Infof("    = plan %s for bucket %s is up to date", current.id, definition.Bucket)
+			}
+
+			if err != nil {
+				logrus.Error(err)
+			}
+		}
+
+		for bucket, plan := range existing {
+			if defined[bucket] {
+				continue
+			}
+
+			if !prune {
+				logrus.Infof("    ! plan %s for bucket %s is not defined, run with --prune to delete it", plan.id, bucket)
+				continue
+			}
+
+			logrus.Infof("    - delete plan %s for bucket %s", plan.id, bucket)
+			if apply {
+				if err := deleteMalwareProtectionPlan(client, plan.id); err != nil {
+					logrus.Error(err)
+				}
+			}
+		}
+	}
+
+	logrus.Info("AWS GuardDuty Malware Protection plan sync complete")
+
+	return nil
+}
+
+// DeleteGuardDutyMalwareProtectionPlans deletes the GuardDuty Malware Protection plans for the S3 buckets in a YAML
+// file. The plans are only deleted when apply is set.
+func DeleteGuardDutyMalwareProtectionPlans(path string, apply bool) error {
+	definitions, err := readGuardDutyMalwareProtectionPlanDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Deleting AWS GuardDuty Malware Protection plans from %s", path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	scopes, grouped := groupMalwareProtectionPlans(definitions)
+	for _, scope := range scopes {
+		logrus.Infof("  Processing %s", scope)
+
+		client := scope.client()
+		existing, err := listMalwareProtectionPlans(client)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		for _, definition := range grouped[scope] {
+			plan, found := existing[definition.Bucket]
+			if !found {
+				logrus.Infof("    no plan found for bucket %s", definition.Bucket)
+				continue
+			}
+
+			logrus.Infof("    - delete plan %s for bucket %s", plan.id, definition.Bucket)
+			if apply {
+				if err := deleteMalwareProtectionPlan(client, plan.id); err != nil {
+					logrus.Error(err)
+				}
+			}
+		}
+	}
+
+	logrus.Info("Deleting AWS GuardDuty Malware Protection plans complete")
+
+	return nil
+}
+
+// ReportGuardDutyMalwareProtectionPlans writes the status of the GuardDuty Malware Protection plans for the S3 buckets
+// in a YAML file to stdout
+func ReportGuardDutyMalwareProtectionPlans(path string, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	definitions, err := readGuardDutyMalwareProtectionPlanDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0)
+	scopes, grouped := groupMalwareProtectionPlans(definitions)
+	for _, scope := range scopes {
+		existing, err := listMalwareProtectionPlans(scope.client())
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		for _, definition := range grouped[scope] {
+			plan, found := existing[definition.Bucket]
+			if !found {
+				rows = append(rows, []string{scope.region, definition.Bucket, "-", "NOT_FOUND", "", "", ""})
+				continue
+			}
+
+			rows = append(rows, []string{scope.region, definition.Bucket, plan.id, plan.status, plan.taggingStatus, strings.Join(plan.prefixes, ","), plan.statusReasons})
+		}
+	}
+
+	headers := []string{"REGION", "BUCKET", "PLAN", "STATUS", "TAGGING", "PREFIXES", "REASONS"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
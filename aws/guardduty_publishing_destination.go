@@ -0,0 +1,137 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/sirupsen/logrus"
+)
+
+// findS3PublishingDestination returns the S3 publishing destination of the detector, or nil if there isn't one. A
+// detector can only have a single destination of each type.
+func (detector guardDutyAdminDetector) findS3PublishingDestination() (*guardduty.DescribePublishingDestinationOutput, error) {
+	var destinationID *string
+
+	err := detector.client.ListPublishingDestinationsPages(
+		&guardduty.ListPublishingDestinationsInput{DetectorId: aws.String(detector.detectorID)},
+		func(page *guardduty.ListPublishingDestinationsOutput, lastPage bool) bool {
+			for _, destination := range page.Destinations {
+				if *destination.DestinationType == guardduty.DestinationTypeS3 {
+					destinationID = destination.DestinationId
+					return false
+				}
+			}
+			return true
+		})
+	if err != nil || destinationID == nil {
+		return nil, err
+	}
+
+	return detector.client.DescribePublishingDestination(&guardduty.DescribePublishingDestinationInput{
+		DestinationId: destinationID,
+		DetectorId:    aws.String(detector.detectorID),
+	})
+}
+
+// setS3PublishingDestination creates the S3 publishing destination of the detector, or updates it when it exists with
+// different properties, and returns the ID of the destination
+func (detector guardDutyAdminDetector) setS3PublishingDestination(properties *guardduty.DestinationProperties) (string, error) {
+	existing, err := detector.findS3PublishingDestination()
+	if err != nil {
+		return "", err
+	}
+
+	if existing == nil {
+		logrus.Infof("    creating S3 publishing destination %s", *properties.DestinationArn)
+		result, err := detector.client.CreatePublishingDestination(&guardduty.CreatePublishingDestinationInput{
+			DestinationProperties: properties,
+			DestinationType:       aws.String(guardduty.DestinationTypeS3),
+			DetectorId:            aws.String(detector.detectorID),
+		})
+		if err != nil {
+			return "", err
+		}
+		return *result.DestinationId, nil
+	}
+
+	current := existing.DestinationProperties
+	if aws.StringValue(current.DestinationArn) == *properties.DestinationArn && aws.StringValue(current.KmsKeyArn) == *properties.KmsKeyArn {
+		logrus.Infof("    S3 publishing destination %s is already configured, skipping", *properties.DestinationArn)
+		return *existing.DestinationId, nil
+	}
+
+	logrus.Infof("    updating S3 publishing destination %s to %s", aws.StringValue(current.DestinationArn), *properties.DestinationArn)
+	_, err = detector.client.UpdatePublishingDestination(&guardduty.UpdatePublishingDestinationInput{
+		DestinationId:         existing.DestinationId,
+		DestinationProperties: properties,
+		DetectorId:            aws.String(detector.detectorID),
+	})
+
+	return *existing.DestinationId, err
+}
+
+func (detector guardDutyAdminDetector) logPublishingDestinationStatus(destinationID string) {
+	destination, err := detector.client.DescribePublishingDestination(&guardduty.DescribePublishingDestinationInput{
+		DestinationId: aws.String(destinationID),
+		DetectorId:    aws.String(detector.detectorID),
+	})
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	if *destination.Status == guardduty.PublishingStatusPublishing {
+		logrus.Infof("    destination %s status: %s", destinationID, *destination.Status)
+	} else {
+		logrus.Warnf("    destination %s status: %s", destinationID, *destination.Status)
+	}
+}
+
+// SetGuardDutyPublishingDestination creates or updates the S3 publishing destination of the GuardDuty Administrator
+// Account's detector in every enabled region so that findings are exported to S3, encrypted with a KMS key
+func SetGuardDutyPublishingDestination(region string, administratorAccountRole string, destinationArn string, kmsKeyArn string) error {
+	if destinationArn == "" || kmsKeyArn == "" {
+		return errors.New("Both the destination ARN and the KMS key ARN must be provided")
+	}
+
+	properties := &guardduty.DestinationProperties{
+		DestinationArn: aws.String(destinationArn),
+		KmsKeyArn:      aws.String(kmsKeyArn),
+	}
+
+	logrus.Infof("Setting AWS GuardDuty publishing destination %s", destinationArn)
+
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		logrus.Infof("  Processing region %s", detector.region)
+
+		destinationID, err := detector.setS3PublishingDestination(properties)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		detector.logPublishingDestinationStatus(destinationID)
+	}
+
+	logrus.Info("AWS GuardDuty publishing destination complete")
+
+	return nil
+}
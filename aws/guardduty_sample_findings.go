@@ -0,0 +1,198 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// sampleFindingsRun tracks the sample findings created in a single region
+type sampleFindingsRun struct {
+	detector  guardDutyAdminDetector
+	createdAt time.Time
+	baseline  map[string]string
+	elapsed   time.Duration
+	found     bool
+	err       error
+}
+
+// sampleFindingCriteria selects the sample findings of the requested types, or of every type when none were requested
+func sampleFindingCriteria(findingTypes []string) *guardduty.FindingCriteria {
+	criterion := map[string]*guardduty.Condition{
+		"service.additionalInfo.sample": {Equals: aws.StringSlice([]string{"true"})},
+	}
+
+	if len(findingTypes) > 0 {
+		criterion["type"] = &guardduty.Condition{Equals: aws.StringSlice(findingTypes)}
+	}
+
+	return &guardduty.FindingCriteria{Criterion: criterion}
+}
+
+// sampleFindingUpdates returns when the sample findings of each type were last updated. GuardDuty updates the existing
+// sample finding of a type when samples of that type are created again.
+func (detector guardDutyAdminDetector) sampleFindingUpdates(findingTypes []string) (map[string]string, error) {
+	findingIDs := make([]*string, 0)
+	err := detector.client.ListFindingsPages(
+		&guardduty.ListFindingsInput{DetectorId: aws.String(detector.detectorID), FindingCriteria: sampleFindingCriteria(findingTypes)},
+		func(page *guardduty.ListFindingsOutput, lastPage bool) bool {
+			findingIDs = append(findingIDs, page.FindingIds...)
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]string)
+	for start := 0; start < len(findingIDs); start += guardDutyGetFindingsBatchSize {
+		end := start + guardDutyGetFindingsBatchSize
+		if end > len(findingIDs) {
+			end = len(findingIDs)
+		}
+
+		result, err := detector.client.GetFindings(&guardduty.GetFindingsInput{
+			DetectorId: aws.String(detector.detectorID),
+			FindingIds: findingIDs[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Timestamps are ISO 8601 in UTC, so they compare as strings
+		for _, finding := range result.Findings {
+			findingType := aws.StringValue(finding.Type)
+			if updatedAt := aws.StringValue(finding.UpdatedAt); updatedAt > updates[findingType] {
+				updates[findingType] = updatedAt
+			}
+		}
+	}
+
+	return updates, nil
+}
+
+// samplesAppeared reports whether a sample finding of each requested type was created or updated since the run
+// started. When no finding types were requested GuardDuty creates one of each type, so any sample finding counts.
+func (run sampleFindingsRun) samplesAppeared(findingTypes []string) (bool, error) {
+	updates, err := run.detector.sampleFindingUpdates(findingTypes)
+	if err != nil {
+		return false, err
+	}
+
+	if len(findingTypes) == 0 {
+		for findingType, updatedAt := range updates {
+			if updatedAt > run.baseline[findingType] {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	for _, findingType := range findingTypes {
+		if updates[findingType] <= run.baseline[findingType] {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (run sampleFindingsRun) row() []string {
+	switch {
+	case run.err != nil:
+		return []string{run.detector.region, "ERROR", "-", run.err.Error()}
+	case run.found:
+		return []string{run.detector.region, "FOUND", run.elapsed.Round(time.Second).String(), ""}
+	default:
+		return []string{run.detector.region, "TIMED_OUT", "-", ""}
+	}
+}
+
+// CreateGuardDutySampleFindings creates sample findings on the GuardDuty Administrator Account's detector in the
+// selected regions, then polls until they can be listed and writes how long that took in each region to stdout. When
+// no finding types are provided, a sample of every finding type is created.
+func CreateGuardDutySampleFindings(region string, administratorAccountRole string, regions []string, findingTypes []string, timeout time.Duration, pollInterval time.Duration, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	enabledRegions := GetEnabledRegions(region, administratorAccountRole, false)
+	selectedRegions, err := SelectRegions(enabledRegions, regions)
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Creating AWS GuardDuty sample findings")
+
+	runs := make([]*sampleFindingsRun, 0)
+	for _, detector := range getGuardDutyAdminDetectorsInRegions(selectedRegions, administratorAccountRole) {
+		logrus.Infof("  creating sample findings in region %s", detector.region)
+
+		run := &sampleFindingsRun{detector: detector}
+		runs = append(runs, run)
+
+		run.baseline, run.err = detector.sampleFindingUpdates(findingTypes)
+		if run.err != nil {
+			continue
+		}
+
+		run.createdAt = time.Now()
+		_, run.err = detector.client.CreateSampleFindings(&guardduty.CreateSampleFindingsInput{
+			DetectorId:   aws.String(detector.detectorID),
+			FindingTypes: aws.StringSlice(findingTypes),
+		})
+	}
+
+	logrus.Infof("Waiting up to %s for the sample findings to appear", timeout)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := 0
+		for _, run := range runs {
+			if run.found || run.err != nil {
+				continue
+			}
+
+			run.found, run.err = run.samplesAppeared(findingTypes)
+			if run.found {
+				run.elapsed = time.Since(run.createdAt)
+				logrus.Infof("  sample findings appeared in region %s after %s", run.detector.region, run.elapsed.Round(time.Second))
+			} else if run.err == nil {
+				pending++
+			}
+		}
+
+		if pending == 0 || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(pollInterval)
+	}
+
+	rows := make([][]string, 0, len(runs))
+	for _, run := range runs {
+		rows = append(rows, run.row())
+	}
+
+	headers := []string{"REGION", "RESULT", "ELAPSED", "ERROR"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
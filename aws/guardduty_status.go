@@ -0,0 +1,208 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+const guardDutyAccountTypeAdministrator = "administrator"
+const guardDutyAccountTypeManagement = "management"
+const guardDutyAccountTypeMember = "member"
+const guardDutyAccountTypeExternal = "external"
+
+// guardDutyDetectorStatus is a single row of the GuardDuty status report
+type guardDutyDetectorStatus struct {
+	region       string
+	accountID    string
+	accountType  string
+	detectorID   string
+	status       string
+	relationship string
+	invitedAt    string
+	frequency    string
+	drift        string
+}
+
+func (status guardDutyDetectorStatus) row() []string {
+	return []string{status.region, status.accountID, status.accountType, status.detectorID, status.status, status.relationship, status.invitedAt, status.frequency, status.drift}
+}
+
+// getGuardDutyDetectorStatus describes the detector of an account in a region, as seen by the account itself. isMember
+// is whether the account is a member of the Administrator Account, whose detector settings it then inherits.
+func getGuardDutyDetectorStatus(client *guardduty.GuardDuty, region string, accountID string, accountType string, isMember bool, settings GuardDutyDetectorSettings) guardDutyDetectorStatus {
+	status := guardDutyDetectorStatus{region: region, accountID: accountID, accountType: accountType, status: "-", relationship: "-", invitedAt: "-", frequency: "-", drift: "-"}
+
+	status.detectorID = getDetectorIDForRegion(client)
+	if status.detectorID == "" {
+		status.detectorID = "-"
+		status.status = "NO_DETECTOR"
+		return status
+	}
+
+	detector, err := client.GetDetector(&guardduty.GetDetectorInput{DetectorId: aws.String(status.detectorID)})
+	if err != nil {
+		logrus.Error(err)
+		return status
+	}
+
+	status.status = aws.StringValue(detector.Status)
+	status.frequency = aws.StringValue(detector.FindingPublishingFrequency)
+	status.drift = formatGuardDutyDrift(settings.drift(detector, isMember))
+
+	return status
+}
+
+// listGuardDutyMembers returns all of the members of the Administrator Account's detector, including accounts that
+// haven't accepted an invitation, keyed by account ID
+func listGuardDutyMembers(client *guardduty.GuardDuty, detectorID string) (map[string]*guardduty.Member, error) {
+	members := make(map[string]*guardduty.Member)
+	err := client.ListMembersPages(
+		&guardduty.ListMembersInput{DetectorId: aws.String(detectorID), OnlyAssociated: aws.String("false")},
+		func(page *guardduty.ListMembersOutput, lastPage bool) bool {
+			for _, member := range page.Members {
+				members[*member.AccountId] = member
+			}
+			return true
+		})
+
+	return members, err
+}
+
+// isGuardDutyMember returns whether the account is a member of the Administrator Account's detector. The management
+// account is usually enrolled as a member, and its detector then inherits the member settings.
+func isGuardDutyMember(client *guardduty.GuardDuty, detectorID string, accountID string) bool {
+	if detectorID == "" {
+		return false
+	}
+
+	members, err := listGuardDutyMembers(client, detectorID)
+	if err != nil {
+		logrus.Error(err)
+		return false
+	}
+
+	_, found := members[accountID]
+	return found
+}
+
+// ReportGuardDutyStatus writes the status of the detectors of the GuardDuty Administrator Account, the AWS Management
+// Account and the member accounts, including invited accounts outside of the AWS Organization, in every enabled region
+// to stdout. Drift from the detector settings is reported for each detector. The detectors of member accounts are only
+// inspected when memberRoleName is provided, otherwise only their relationship with the Administrator Account is
+// reported.
+func ReportGuardDutyStatus(region string, administratorAccountRole string, rootRole string, memberRoleName string, settings GuardDutyDetectorSettings, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	rootSession := GetSession()
+	rootAccountID := GetAccountIDWithRole(rootSession, rootRole)
+
+	adminAcctSession := GetSession()
+	adminAccountID := GetAccountIDWithRole(adminAcctSession, administratorAccountRole)
+
+	enabledRegions := GetEnabledRegions(region, rootRole, false)
+
+	organizationAccounts := make(map[string]bool)
+	for _, accountID := range ListMemberAccountIDs(rootRole) {
+		organizationAccounts[accountID] = true
+	}
+
+	rows := make([][]string, 0)
+	for r := range enabledRegions {
+		currentRegion := enabledRegions[r]
+		logrus.Infof("  Processing region %s", currentRegion)
+
+		adminAccountClient := getGuardDutyClient(currentRegion, administratorAccountRole)
+		adminStatus := getGuardDutyDetectorStatus(adminAccountClient, currentRegion, adminAccountID, guardDutyAccountTypeAdministrator, false, settings)
+		rows = append(rows, adminStatus.row())
+
+		members := make(map[string]*guardduty.Member)
+		membersListed := false
+		if adminStatus.detectorID != "-" {
+			var err error
+			members, err = listGuardDutyMembers(adminAccountClient, adminStatus.detectorID)
+			if err != nil {
+				logrus.Error(err)
+			} else {
+				membersListed = true
+			}
+		}
+
+		// The management account is usually enrolled as a member too, and then inherits the member settings
+		rootAccountClient := getGuardDutyClient(currentRegion, rootRole)
+		_, rootIsMember := members[rootAccountID]
+		rootStatus := getGuardDutyDetectorStatus(rootAccountClient, currentRegion, rootAccountID, guardDutyAccountTypeManagement, rootIsMember, settings)
+
+		if !membersListed {
+			rows = append(rows, rootStatus.row())
+			continue
+		}
+
+		if member, found := members[rootAccountID]; found {
+			rootStatus.relationship = aws.StringValue(member.RelationshipStatus)
+			if member.InvitedAt != nil {
+				rootStatus.invitedAt = aws.StringValue(member.InvitedAt)
+			}
+		}
+		rows = append(rows, rootStatus.row())
+
+		accountIDs := make([]string, 0, len(members))
+		for accountID := range members {
+			accountIDs = append(accountIDs, accountID)
+		}
+		sort.Strings(accountIDs)
+
+		for _, accountID := range accountIDs {
+			if accountID == rootAccountID {
+				continue
+			}
+
+			member := members[accountID]
+			accountType := guardDutyAccountTypeMember
+			if !organizationAccounts[accountID] {
+				accountType = guardDutyAccountTypeExternal
+			}
+
+			memberStatus := guardDutyDetectorStatus{region: currentRegion, accountID: accountID, accountType: accountType, detectorID: aws.StringValue(member.DetectorId), status: "-", invitedAt: "-", frequency: "-", drift: "-"}
+			if memberRoleName != "" {
+				memberClient := getGuardDutyClient(currentRegion, GetMemberRoleArn(accountID, memberRoleName))
+				memberStatus = getGuardDutyDetectorStatus(memberClient, currentRegion, accountID, accountType, true, settings)
+			}
+			memberStatus.relationship = aws.StringValue(member.RelationshipStatus)
+			if member.InvitedAt != nil {
+				memberStatus.invitedAt = aws.StringValue(member.InvitedAt)
+			}
+
+			rows = append(rows, memberStatus.row())
+		}
+	}
+
+	headers := []string{"REGION", "ACCOUNT", "TYPE", "DETECTOR", "STATUS", "RELATIONSHIP", "INVITED", "FREQUENCY", "DRIFT"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
@@ -0,0 +1,308 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// GuardDutyThreatListDefinition describes a GuardDuty trusted IP list (IPSet) or threat list (ThreatIntelSet)
+type GuardDutyThreatListDefinition struct {
+	Name     string `yaml:"name"`
+	Location string `yaml:"location"`
+	Format   string `yaml:"format"`
+	Activate bool   `yaml:"activate"`
+}
+
+// GuardDutyThreatListDefinitions is the format of the file read by SyncGuardDutyThreatLists
+type GuardDutyThreatListDefinitions struct {
+	IPSets          []GuardDutyThreatListDefinition `yaml:"ipSets"`
+	ThreatIntelSets []GuardDutyThreatListDefinition `yaml:"threatIntelSets"`
+}
+
+func (definition GuardDutyThreatListDefinition) validate() error {
+	if definition.Name == "" || definition.Location == "" {
+		return fmt.Errorf("both the name and location of a list must be provided")
+	}
+
+	for _, format := range guardduty.IpSetFormat_Values() {
+		if definition.Format == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("list %s has invalid format %s, must be one of %v", definition.Name, definition.Format, guardduty.IpSetFormat_Values())
+}
+
+// guardDutyThreatList is an existing IPSet or ThreatIntelSet
+type guardDutyThreatList struct {
+	ID       string
+	Name     string
+	Location string
+	Format   string
+	Status   string
+}
+
+func (list guardDutyThreatList) isActive() bool {
+	return list.Status == guardduty.IpSetStatusActive || list.Status == guardduty.IpSetStatusActivating
+}
+
+// guardDutyThreatListAPI abstracts over the IPSet and ThreatIntelSet APIs, which are otherwise identical
+type guardDutyThreatListAPI interface {
+	kind() string
+	list() (map[string]guardDutyThreatList, error)
+	create(definition GuardDutyThreatListDefinition) error
+	update(id string, definition GuardDutyThreatListDefinition) error
+}
+
+type guardDutyIPSetAPI struct {
+	detector guardDutyAdminDetector
+}
+
+func (api guardDutyIPSetAPI) kind() string {
+	return "IPSet"
+}
+
+func (api guardDutyIPSetAPI) list() (map[string]guardDutyThreatList, error) {
+	ids := make([]*string, 0)
+	err := api.detector.client.ListIPSetsPages(
+		&guardduty.ListIPSetsInput{DetectorId: aws.String(api.detector.detectorID)},
+		func(page *guardduty.ListIPSetsOutput, lastPage bool) bool {
+			ids = append(ids, page.IpSetIds...)
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string]guardDutyThreatList)
+	for _, id := range ids {
+		ipSet, err := api.detector.client.GetIPSet(&guardduty.GetIPSetInput{DetectorId: aws.String(api.detector.detectorID), IpSetId: id})
+		if err != nil {
+			return nil, err
+		}
+		lists[*ipSet.Name] = guardDutyThreatList{ID: *id, Name: *ipSet.Name, Location: *ipSet.Location, Format: *ipSet.Format, Status: *ipSet.Status}
+	}
+
+	return lists, nil
+}
+
+func (api guardDutyIPSetAPI) create(definition GuardDutyThreatListDefinition) error {
+	_, err := api.detector.client.CreateIPSet(&guardduty.CreateIPSetInput{
+		Activate:   aws.Bool(definition.Activate),
+		DetectorId: aws.String(api.detector.detectorID),
+		Format:     aws.String(definition.Format),
+		Location:   aws.String(definition.Location),
+		Name:       aws.String(definition.Name),
+	})
+	return err
+}
+
+func (api guardDutyIPSetAPI) update(id string, definition GuardDutyThreatListDefinition) error {
+	_, err := api.detector.client.UpdateIPSet(&guardduty.UpdateIPSetInput{
+		Activate:   aws.Bool(definition.Activate),
+		DetectorId: aws.String(api.detector.detectorID),
+		IpSetId:    aws.String(id),
+		Location:   aws.String(definition.Location),
+		Name:       aws.String(definition.Name),
+	})
+	return err
+}
+
+type guardDutyThreatIntelSetAPI struct {
+	detector guardDutyAdminDetector
+}
+
+func (api guardDutyThreatIntelSetAPI) kind() string {
+	return "ThreatIntelSet"
+}
+
+func (api guardDutyThreatIntelSetAPI) list() (map[string]guardDutyThreatList, error) {
+	ids := make([]*string, 0)
+	err := api.detector.client.ListThreatIntelSetsPages(
+		&guardduty.ListThreatIntelSetsInput{DetectorId: aws.String(api.detector.detectorID)},
+		func(page *guardduty.ListThreatIntelSetsOutput, lastPage bool) bool {
+			ids = append(ids, page.ThreatIntelSetIds...)
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	lists := make(map[string]guardDutyThreatList)
+	for _, id := range ids {
+		set, err := api.detector.client.GetThreatIntelSet(&guardduty.GetThreatIntelSetInput{DetectorId: aws.String(api.detector.detectorID), ThreatIntelSetId: id})
+		if err != nil {
+			return nil, err
+		}
+		lists[*set.Name] = guardDutyThreatList{ID: *id, Name: *set.Name, Location: *set.Location, Format: *set.Format, Status: *set.Status}
+	}
+
+	return lists, nil
+}
+
+func (api guardDutyThreatIntelSetAPI) create(definition GuardDutyThreatListDefinition) error {
+	_, err := api.detector.client.CreateThreatIntelSet(&guardduty.CreateThreatIntelSetInput{
+		Activate:   aws.Bool(definition.Activate),
+		DetectorId: aws.String(api.detector.detectorID),
+		Format:     aws.String(definition.Format),
+		Location:   aws.String(definition.Location),
+		Name:       aws.String(definition.Name),
+	})
+	return err
+}
+
+func (api guardDutyThreatIntelSetAPI) update(id string, definition GuardDutyThreatListDefinition) error {
+	_, err := api.detector.client.UpdateThreatIntelSet(&guardduty.UpdateThreatIntelSetInput{
+		Activate:         aws.Bool(definition.Activate),
+		DetectorId:       aws.String(api.detector.detectorID),
+		Location:         aws.String(definition.Location),
+		Name:             aws.String(definition.Name),
+		ThreatIntelSetId: aws.String(id),
+	})
+	return err
+}
+
+func (detector guardDutyAdminDetector) threatListAPIs() []guardDutyThreatListAPI {
+	return []guardDutyThreatListAPI{guardDutyIPSetAPI{detector: detector}, guardDutyThreatIntelSetAPI{detector: detector}}
+}
+
+// syncThreatLists creates the lists that don't exist yet and updates the location and activation of the ones that do.
+// The format of an existing list can't be changed, so a mismatch is only reported.
+func syncThreatLists(api guardDutyThreatListAPI, definitions []GuardDutyThreatListDefinition, apply bool) error {
+	existing, err := api.list()
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range definitions {
+		var err error
+		current, found := existing[definition.Name]
+		switch {
+		case !found:
+			logrus.Infof("    + create %s %s (%s, activate: %t)", api.kind(), definition.Name, definition.Format, definition.Activate)
+			if apply {
+				err = api.create(definition)
+			}
+		case current.Format != definition.Format:
+			logrus.Warnf("    ! %s %s has format %s, it must be deleted to change the format to %s", api.kind(), definition.Name, current.Format, definition.Format)
+		case current.Location != definition.Location || current.isActive() != definition.Activate:
+			logrus.Infof("    ~ update %s %s (%s, activate: %t)", api.kind(), definition.Name, definition.Location, definition.Activate)
+			if apply {
+				err = api.update(current.ID, definition)
+			}
+		default:
+			logrus.Infof("    = %s %s is up to date", api.kind(), definition.Name)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	return nil
+}
+
+func readGuardDutyThreatListDefinitions(path string) (GuardDutyThreatListDefinitions, error) {
+	definitions := GuardDutyThreatListDefinitions{}
+	if err := common.ReadYAMLFile(path, &definitions); err != nil {
+		return definitions, err
+	}
+
+	for _, definition := range append(definitions.IPSets, definitions.ThreatIntelSets...) {
+		if err := definition.validate(); err != nil {
+			return definitions, err
+		}
+	}
+
+	return definitions, nil
+}
+
+// SyncGuardDutyThreatLists creates, updates, activates and deactivates the trusted IP lists and threat lists of the
+// GuardDuty Administrator Account's detector in every enabled region to match the definitions in a YAML file. The
+// planned changes are always shown and are only made when apply is set.
+func SyncGuardDutyThreatLists(region string, administratorAccountRole string, path string, apply bool) error {
+	definitions, err := readGuardDutyThreatListDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Syncing AWS GuardDuty trusted IP lists and threat lists from %s", path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		logrus.Infof("  Processing region %s", detector.region)
+
+		if err := syncThreatLists(guardDutyIPSetAPI{detector: detector}, definitions.IPSets, apply); err != nil {
+			logrus.Error(err)
+		}
+		if err := syncThreatLists(guardDutyThreatIntelSetAPI{detector: detector}, definitions.ThreatIntelSets, apply); err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	logrus.Info("AWS GuardDuty trusted IP list and threat list sync complete")
+
+	return nil
+}
+
+// ReportGuardDutyThreatLists writes the status of every trusted IP list and threat list of the GuardDuty Administrator
+// Account's detector in every enabled region to stdout
+func ReportGuardDutyThreatLists(region string, administratorAccountRole string, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	headers := []string{"REGION", "KIND", "NAME", "FORMAT", "STATUS", "LOCATION"}
+	rows := make([][]string, 0)
+
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		for _, api := range detector.threatListAPIs() {
+			lists, err := api.list()
+			if err != nil {
+				logrus.Error(err)
+				continue
+			}
+
+			names := make([]string, 0, len(lists))
+			for name := range lists {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				list := lists[name]
+				rows = append(rows, []string{detector.region, api.kind(), list.Name, list.Format, list.Status, list.Location})
+			}
+		}
+	}
+
+	return output.Rows(os.Stdout, format, headers, rows)
+}
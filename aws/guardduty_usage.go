@@ -0,0 +1,223 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/guardduty"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// GuardDuty reports usage over a trailing window of this many days
+const guardDutyUsageWindowDays = 30
+
+// These are the ways usage can be broken down within each account
+const (
+	GuardDutyUsageByFeature    string = "feature"
+	GuardDutyUsageByDataSource string = "data-source"
+)
+
+const guardDutyUsageAll = "ALL"
+
+// guardDutyUsage is the usage of a single account, in a single region, for a single feature or data source
+type guardDutyUsage struct {
+	region    string
+	accountID string
+	source    string
+	amount    float64
+	unit      string
+}
+
+func parseGuardDutyUsageTotal(total *guardduty.Total) (float64, string) {
+	if total == nil {
+		return 0, ""
+	}
+
+	amount, err := strconv.ParseFloat(aws.StringValue(total.Amount), 64)
+	if err != nil {
+		logrus.Warnf("    unable to parse usage amount %s", aws.StringValue(total.Amount))
+	}
+
+	return amount, aws.StringValue(total.Unit)
+}
+
+func (detector guardDutyAdminDetector) getUsageStatistics(statisticType string, accountIDs []string) ([]*guardduty.UsageStatistics, error) {
+	criteria := &guardduty.UsageCriteria{}
+	if len(accountIDs) > 0 {
+		criteria.AccountIds = aws.StringSlice(accountIDs)
+	}
+
+	// Usage is only aggregated from the data sources or features listed in the criteria
+	if statisticType == guardduty.UsageStatisticTypeSumByDataSource {
+		criteria.DataSources = aws.StringSlice(guardduty.DataSource_Values())
+	} else {
+		criteria.Features = aws.StringSlice(guardduty.UsageFeature_Values())
+	}
+
+	statistics := make([]*guardduty.UsageStatistics, 0)
+	err := detector.client.GetUsageStatisticsPages(
+		&guardduty.GetUsageStatisticsInput{
+			DetectorId:         aws.String(detector.detectorID),
+			UsageCriteria:      criteria,
+			UsageStatisticType: aws.String(statisticType),
+		},
+		func(page *guardduty.GetUsageStatisticsOutput, lastPage bool) bool {
+			if page.UsageStatistics != nil {
+				statistics = append(statistics, page.UsageStatistics)
+			}
+			return true
+		})
+
+	return statistics, err
+}
+
+// getAccountUsage breaks the usage of each account down by feature or data source
+func (detector guardDutyAdminDetector) getAccountUsage(accountIDs []string, groupBy string) ([]guardDutyUsage, error) {
+	byAccount, err := detector.getUsageStatistics(guardduty.UsageStatisticTypeSumByAccount, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]guardDutyUsage, 0)
+	for _, statistics := range byAccount {
+		for _, account := range statistics.SumByAccount {
+			accountID := aws.StringValue(account.AccountId)
+
+			statisticType := guardduty.UsageStatisticTypeSumByFeatures
+			if groupBy == GuardDutyUsageByDataSource {
+				statisticType = guardduty.UsageStatisticTypeSumByDataSource
+			}
+
+			breakdown, err := detector.getUsageStatistics(statisticType, []string{accountID})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, result := range breakdown {
+				for _, feature := range result.SumByFeature {
+					amount, unit := parseGuardDutyUsageTotal(feature.Total)
+					usage = append(usage, guardDutyUsage{region: detector.region, accountID: accountID, source: aws.StringValue(feature.Feature), amount: amount, unit: unit})
+				}
+				for _, dataSource := range result.SumByDataSource {
+					amount, unit := parseGuardDutyUsageTotal(dataSource.Total)
+					usage = append(usage, guardDutyUsage{region: detector.region, accountID: accountID, source: aws.StringValue(dataSource.DataSource), amount: amount, unit: unit})
+				}
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// rollUpGuardDutyUsage totals the usage per account across all regions, per region across all accounts, and overall
+func rollUpGuardDutyUsage(usage []guardDutyUsage) []guardDutyUsage {
+	byAccount := make(map[string]float64)
+	byRegion := make(map[string]float64)
+	var total float64
+	unit := ""
+
+	for _, current := range usage {
+		byAccount[current.accountID] += current.amount
+		byRegion[current.region] += current.amount
+		total += current.amount
+		if current.unit != "" {
+			unit = current.unit
+		}
+	}
+
+	rollUps := make([]guardDutyUsage, 0)
+	for accountID, amount := range byAccount {
+		rollUps = append(rollUps, guardDutyUsage{region: guardDutyUsageAll, accountID: accountID, source: guardDutyUsageAll, amount: amount, unit: unit})
+	}
+	for region, amount := range byRegion {
+		rollUps = append(rollUps, guardDutyUsage{region: region, accountID: guardDutyUsageAll, source: guardDutyUsageAll, amount: amount, unit: unit})
+	}
+	sortGuardDutyUsage(rollUps)
+
+	return append(rollUps, guardDutyUsage{region: guardDutyUsageAll, accountID: guardDutyUsageAll, source: guardDutyUsageAll, amount: total, unit: unit})
+}
+
+func sortGuardDutyUsage(usage []guardDutyUsage) {
+	sort.SliceStable(usage, func(i, j int) bool {
+		if usage[i].region != usage[j].region {
+			return usage[i].region < usage[j].region
+		}
+		if usage[i].accountID != usage[j].accountID {
+			return usage[i].accountID < usage[j].accountID
+		}
+		return usage[i].source < usage[j].source
+	})
+}
+
+// ReportGuardDutyUsage writes the usage of each account from the GuardDuty Administrator Account's detector in every
+// enabled region to stdout, broken down by feature or data source and rolled up per account, per region and overall.
+// GuardDuty only reports usage for the trailing 30 days, so the usage is also projected over the requested number of
+// days to estimate the cost of that period.
+func ReportGuardDutyUsage(region string, administratorAccountRole string, accountIDs []string, groupBy string, days int, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	if groupBy != GuardDutyUsageByFeature && groupBy != GuardDutyUsageByDataSource {
+		return fmt.Errorf("%s is not a valid grouping, must be %s or %s", groupBy, GuardDutyUsageByFeature, GuardDutyUsageByDataSource)
+	}
+
+	if days < 1 {
+		return fmt.Errorf("the number of days must be at least 1")
+	}
+
+	logrus.Info("Querying AWS GuardDuty usage statistics")
+
+	usage := make([]guardDutyUsage, 0)
+	detectors := getGuardDutyAdminDetectors(region, administratorAccountRole)
+	for _, detector := range detectors {
+		logrus.Infof("  Processing region %s", detector.region)
+
+		regionUsage, err := detector.getAccountUsage(accountIDs, groupBy)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		usage = append(usage, regionUsage...)
+	}
+
+	sortGuardDutyUsage(usage)
+	usage = append(usage, rollUpGuardDutyUsage(usage)...)
+
+	projection := float64(days) / guardDutyUsageWindowDays
+	headers := []string{"REGION", "ACCOUNT", "SOURCE", fmt.Sprintf("LAST_%d_DAYS", guardDutyUsageWindowDays), fmt.Sprintf("PROJECTED_%d_DAYS", days), "UNIT"}
+	rows := make([][]string, 0, len(usage))
+	for _, current := range usage {
+		rows = append(rows, []string{
+			current.region,
+			current.accountID,
+			current.source,
+			strconv.FormatFloat(current.amount, 'f', 2, 64),
+			strconv.FormatFloat(current.amount*projection, 'f', 2, 64),
+			current.unit,
+		})
+	}
+
+	return output.Rows(os.Stdout, format, headers, rows)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// GuardDuty and Security Hub accept at most 50 accounts per CreateMembers request
+const createMembersBatchSize = 50
+
+// createMembersMaxAttempts is the number of times a batch is sent to CreateMembers before giving up on it
+const createMembersMaxAttempts = 4
+
+// memberResultCreated is recorded for accounts that were created as members
+const memberResultCreated = "Created"
+
+// memberBatch is a function that sends a batch of account IDs to CreateMembers and returns the result of each account
+// that wasn't processed
+type memberBatch func(accountIDs []string) (map[string]string, error)
+
+// createMembersBackoff returns how long to wait before the given retry attempt
+func createMembersBackoff(attempt int) time.Duration {
+	return time.Duration(1<<uint(attempt-1)) * time.Second
+}
+
+// isTransientMemberError returns whether a failed CreateMembers request is throttling or a transient failure, based
+// on its error code
+func isTransientMemberError(err error) bool {
+	if _, ok := err.(awserr.Error); !ok {
+		return false
+	}
+	return request.IsErrorThrottle(err) || request.IsErrorRetryable(err)
+}
+
+// batchAccountIDs splits the account IDs into batches of at most size accounts
+func batchAccountIDs(accountIDs []string, size int) [][]string {
+	batches := make([][]string, 0)
+	for start := 0; start < len(accountIDs); start += size {
+		end := start + size
+		if end > len(accountIDs) {
+			end = len(accountIDs)
+		}
+		batches = append(batches, accountIDs[start:end])
+	}
+	return batches
+}
+
+// createMembersInBatches sends the accounts to CreateMembers in batches and returns the final result of each account.
+// Batches whose request was throttled or failed with a transient error are retried with an exponential backoff. The
+// result of an account that wasn't processed is final, such as an account that already belongs to another
+// administrator or an invalid email.
+func createMembersInBatches(accountIDs []string, createMembers memberBatch) map[string]string {
+	results := make(map[string]string)
+
+	pending := accountIDs
+	for attempt := 0; attempt < createMembersMaxAttempts && len(pending) > 0; attempt++ {
+		if attempt > 0 {
+			backoff := createMembersBackoff(attempt)
+			logrus.Infof("    retrying %d accounts in %s", len(pending), backoff)
+			time.Sleep(backoff)
+		}
+
+		retry := make([]string, 0)
+		for _, batch := range batchAccountIDs(pending, createMembersBatchSize) {
+			unprocessed, err := createMembers(batch)
+			if err != nil {
+				logrus.Error(err)
+				for _, accountID := range batch {
+					results[accountID] = err.Error()
+				}
+				if isTransientMemberError(err) {
+					retry = append(retry, batch...)
+				}
+				continue
+			}
+
+			for _, accountID := range batch {
+				if result, found := unprocessed[accountID]; found {
+					results[accountID] = result
+				} else {
+					results[accountID] = memberResultCreated
+				}
+			}
+		}
+
+		pending = retry
+	}
+
+	return results
+}
+
+// logMemberResults logs the final result of each account, as errors for those that weren't created
+func logMemberResults(results map[string]string) {
+	accountIDs := make([]string, 0, len(results))
+	for accountID := range results {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	created := 0
+	for _, accountID := range accountIDs {
+		if results[accountID] == memberResultCreated {
+			created++
+			continue
+		}
+		logrus.Errorf("    account %s was not added as a member: %s", accountID, results[accountID])
+	}
+
+	logrus.Infof("    %d of %d accounts added as members", created, len(results))
+}
+
+// memberResultRows returns a row with the final result of each account in the region, sorted by account ID, and the
+// number of accounts that weren't created
+func memberResultRows(region string, results map[string]string) ([][]string, int) {
+	accountIDs := make([]string, 0, len(results))
+	for accountID := range results {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	failed := 0
+	rows := make([][]string, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if results[accountID] != memberResultCreated {
+			failed++
+		}
+		rows = append(rows, []string{region, accountID, results[accountID]})
+	}
+
+	return rows, failed
+}
+
+// writeMemberResults writes the final result of each account to stdout and returns an error if any account wasn't
+// added as a member
+func writeMemberResults(rows [][]string, failed int) error {
+	if err := output.Table(os.Stdout, []string{"REGION", "ACCOUNT", "RESULT"}, rows); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d member accounts could not be added", failed)
+	}
+	return nil
+}
+
+// readExternalAccountIDs reads the IDs of the accounts outside of the AWS Organization from a file in the format read
+// by InviteGuardDutyMemberAccounts. No accounts are returned when the path is empty.
+func readExternalAccountIDs(path string) ([]string, error) {
+	if path == "" {
+		return []string{}, nil
+	}
+
+	accounts := GuardDutyExternalAccounts{}
+	if err := common.ReadYAMLFile(path, &accounts); err != nil {
+		return nil, err
+	}
+
+	accountIDs := make([]string, 0, len(accounts.Accounts))
+	for _, account := range accounts.Accounts {
+		if account.AccountID == "" {
+			return nil, fmt.Errorf("the accountId of each account must be provided")
+		}
+		accountIDs = append(accountIDs, account.AccountID)
+	}
+
+	return accountIDs, nil
+}
+
+// staleMemberAccountIDs returns the members that are neither accounts in the AWS Organization nor listed as external
+// accounts. members maps the account ID of each member to its relationship status, which is logged with the decision
+// made for each member outside of the AWS Organization.
+func staleMemberAccountIDs(members map[string]string, organizationAccountIDs []string, externalAccountIDs []string) []string {
+	inOrganization := make(map[string]bool)
+	for _, accountID := range organizationAccountIDs {
+		inOrganization[accountID] = true
+	}
+
+	external := make(map[string]bool)
+	for _, accountID := range externalAccountIDs {
+		external[accountID] = true
+	}
+
+	accountIDs := make([]string, 0, len(members))
+	for accountID := range members {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+
+	stale := make([]string, 0)
+	for _, accountID := range accountIDs {
+		if inOrganization[accountID] {
+			continue
+		}
+
+		if external[accountID] {
+			logrus.Infof("    skipping account %s (%s), it isn't in the AWS Organization but is listed as an external account", accountID, members[accountID])
+			continue
+		}
+
+		logrus.Infof("    account %s (%s) isn't in the AWS Organization or listed as an external account", accountID, members[accountID])
+		stale = append(stale, accountID)
+	}
+
+	return stale
+}
@@ -28,6 +28,21 @@ func getOrgClient(role string) *organizations.Organizations {
 	return organizations.New(sess, &aws.Config{Credentials: creds})
 }
 
+// listAccounts returns every account in the AWS Organization. ListAccounts returns at most 20 accounts per page, so
+// all of the pages are read.
+func listAccounts(role string) []*organizations.Account {
+	client := getOrgClient(role)
+
+	accounts := make([]*organizations.Account, 0)
+	err := client.ListAccountsPages(&organizations.ListAccountsInput{}, func(page *organizations.ListAccountsOutput, lastPage bool) bool {
+		accounts = append(accounts, page.Accounts...)
+		return true
+	})
+	common.AssertErrorNil(err)
+
+	return accounts
+}
+
 // AccountWithEmail contains AccountID and Email
 type AccountWithEmail struct {
 	AccountID string
@@ -36,13 +51,11 @@ type AccountWithEmail struct {
 
 // ListMemberAccountIDs provides a list of AWS Accounts that are members of the AWS Organization
 func ListMemberAccountIDs(role string) []string {
-	client := getOrgClient(role)
-	accounts, err := client.ListAccounts(&organizations.ListAccountsInput{})
-	common.AssertErrorNil(err)
+	accounts := listAccounts(role)
 
 	accountIDs := make([]string, 0)
-	for i := range accounts.Accounts {
-		accountIDs = append(accountIDs, *accounts.Accounts[i].Id)
+	for i := range accounts {
+		accountIDs = append(accountIDs, *accounts[i].Id)
 	}
 
 	return accountIDs
@@ -51,14 +64,47 @@ func ListMemberAccountIDs(role string) []string {
 // ListMemberAccountIDsWithEmails provides a list of AWS Accounts that are members of the AWS Organization along with
 // their email addresses
 func ListMemberAccountIDsWithEmails(role string) []AccountWithEmail {
-	client := getOrgClient(role)
-	accounts, err := client.ListAccounts(&organizations.ListAccountsInput{})
-	common.AssertErrorNil(err)
+	accounts := listAccounts(role)
 
 	accountsList := make([]AccountWithEmail, 0)
-	for i := range accounts.Accounts {
-		accountsList = append(accountsList, AccountWithEmail{AccountID: *accounts.Accounts[i].Id, Email: *accounts.Accounts[i].Email})
+	for i := range accounts {
+		accountsList = append(accountsList, AccountWithEmail{AccountID: *accounts[i].Id, Email: *accounts[i].Email})
 	}
 
 	return accountsList
 }
+
+// ListAccountIDsInOrganizationalUnits provides a list of AWS Accounts in the Organizational Units, including the
+// accounts in any nested Organizational Units
+func ListAccountIDsInOrganizationalUnits(role string, organizationalUnitIDs []string) []string {
+	client := getOrgClient(role)
+
+	accountIDs := make([]string, 0)
+	parents := append([]string{}, organizationalUnitIDs...)
+	for len(parents) > 0 {
+		parentID := parents[0]
+		parents = parents[1:]
+
+		err := client.ListAccountsForParentPages(
+			&organizations.ListAccountsForParentInput{ParentId: aws.String(parentID)},
+			func(page *organizations.ListAccountsForParentOutput, lastPage bool) bool {
+				for i := range page.Accounts {
+					accountIDs = append(accountIDs, *page.Accounts[i].Id)
+				}
+				return true
+			})
+		common.AssertErrorNil(err)
+
+		err = client.ListOrganizationalUnitsForParentPages(
+			&organizations.ListOrganizationalUnitsForParentInput{ParentId: aws.String(parentID)},
+			func(page *organizations.ListOrganizationalUnitsForParentOutput, lastPage bool) bool {
+				for i := range page.OrganizationalUnits {
+					parents = append(parents, *page.OrganizationalUnits[i].Id)
+				}
+				return true
+			})
+		common.AssertErrorNil(err)
+	}
+
+	return accountIDs
+}
@@ -17,8 +17,6 @@ limitations under the License.
 package aws
 
 import (
-	"errors"
-	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -67,40 +65,97 @@ func (hub SecurityHub) enableSecurityHubInManagementAccount() {
 	}
 }
 
-// addMemberAccount adds an account in the AWS Organization as a member of the Security Hub Administrator Account
-func (hub SecurityHub) addSecurityHubMemberAccounts(memberAccounts []string, administratorAcctID string) {
-	accountDetails := make([]*securityhub.AccountDetails, 0)
+// addSecurityHubMemberAccounts adds the accounts in the AWS Organization as members of the Security Hub Administrator
+// Account and returns the final result of each account. CreateMembers accepts at most 50 accounts per request, so the
+// accounts are sent in batches.
+func (hub SecurityHub) addSecurityHubMemberAccounts(memberAccounts []string, administratorAcctID string) map[string]string {
+	accountIDs := make([]string, 0)
 	for i := range memberAccounts {
-		currentAccountID := memberAccounts[i]
-		if currentAccountID != administratorAcctID {
-			accountDetails = append(accountDetails, &securityhub.AccountDetails{AccountId: &currentAccountID})
+		if memberAccounts[i] != administratorAcctID {
+			accountIDs = append(accountIDs, memberAccounts[i])
 		}
 	}
-	input := securityhub.CreateMembersInput{AccountDetails: accountDetails}
-	result, err := hub.adminAccountClient.CreateMembers(&input)
+
+	results := createMembersInBatches(accountIDs, func(batch []string) (map[string]string, error) {
+		accountDetails := make([]*securityhub.AccountDetails, 0, len(batch))
+		for _, accountID := range batch {
+			accountDetails = append(accountDetails, &securityhub.AccountDetails{AccountId: aws.String(accountID)})
+		}
+
+		result, err := hub.adminAccountClient.CreateMembers(&securityhub.CreateMembersInput{AccountDetails: accountDetails})
+		if err != nil {
+			return nil, err
+		}
+
+		unprocessed := make(map[string]string)
+		for _, account := range result.UnprocessedAccounts {
+			unprocessed[aws.StringValue(account.AccountId)] = aws.StringValue(account.ProcessingResult)
+		}
+		return unprocessed, nil
+	})
+
+	logMemberResults(results)
+	return results
+}
+
+// removeStaleSecurityHubMemberAccounts disassociates and deletes members that are no longer accounts in the AWS
+// Organization. Members listed as external accounts are left alone.
+func (hub SecurityHub) removeStaleSecurityHubMemberAccounts(memberAccounts []string, externalAccountIDs []string) {
+	statuses := make(map[string]string)
+	err := hub.adminAccountClient.ListMembersPages(
+		&securityhub.ListMembersInput{OnlyAssociated: aws.Bool(false)},
+		func(page *securityhub.ListMembersOutput, lastPage bool) bool {
+			for _, member := range page.Members {
+				statuses[aws.StringValue(member.AccountId)] = aws.StringValue(member.MemberStatus)
+			}
+			return true
+		})
 	if err != nil {
 		logrus.Error(err)
+		return
 	}
 
-	if len(result.UnprocessedAccounts) > 0 {
-		logrus.Error(result)
+	stale := staleMemberAccountIDs(statuses, memberAccounts, externalAccountIDs)
+	if len(stale) == 0 {
+		logrus.Info("    no stale member accounts found")
+		return
+	}
+
+	for _, batch := range batchAccountIDs(stale, createMembersBatchSize) {
+		logrus.Infof("    removing stale member accounts %v", batch)
+
+		_, err := hub.adminAccountClient.DisassociateMembers(&securityhub.DisassociateMembersInput{AccountIds: aws.StringSlice(batch)})
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		_, err = hub.adminAccountClient.DeleteMembers(&securityhub.DeleteMembersInput{AccountIds: aws.StringSlice(batch)})
+		if err != nil {
+			logrus.Error(err)
+		}
 	}
 }
 
-func (hub SecurityHub) disableControl(currentControl string) error {
-	_, err := hub.currentAccountClient.UpdateStandardsControl(&securityhub.UpdateStandardsControlInput{
-		ControlStatus:       aws.String("DISABLED"),
-		DisabledReason:      aws.String("Global Resources are not collected in this region"),
+func (hub SecurityHub) updateControl(currentControl string, status string, reason string) error {
+	input := &securityhub.UpdateStandardsControlInput{
+		ControlStatus:       aws.String(status),
 		StandardsControlArn: aws.String(currentControl),
-	})
+	}
+
+	if reason != "" {
+		input.DisabledReason = aws.String(reason)
+	}
+
+	_, err := hub.currentAccountClient.UpdateStandardsControl(input)
 
 	if err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			if aerr.Code() == "TooManyRequestsException" {
-				logrus.Warnf("    received too many requests error. Sleeping then trying again while disabling control %s", currentControl)
+				logrus.Warnf("    received too many requests error. Sleeping then trying again while updating control %s", currentControl)
 
 				time.Sleep(2 * time.Second)
-				return hub.disableControl(currentControl)
+				return hub.updateControl(currentControl, status, reason)
 			}
 		}
 	}
@@ -108,87 +163,46 @@ func (hub SecurityHub) disableControl(currentControl string) error {
 	return err
 }
 
-func (hub SecurityHub) disableControls(region string, accountID string, controls []string) {
-	for i := range controls {
-		currentControl := fmt.Sprintf(controls[i], region, accountID)
+func (hub SecurityHub) disableControl(currentControl string, reason string) error {
+	return hub.updateControl(currentControl, securityhub.ControlStatusDisabled, reason)
+}
 
-		logrus.Infof("    disabling control %s", currentControl)
-		err := hub.disableControl(currentControl)
+func (hub SecurityHub) enableControl(currentControl string) error {
+	return hub.updateControl(currentControl, securityhub.ControlStatusEnabled, "")
+}
 
-		if err != nil {
-			logrus.Error(err)
-		}
+func (hub SecurityHub) disableControls(scope securityHubControlScope, controls []SecurityHubControl) {
+	consolidated, err := hub.hasConsolidatedControls()
+	if err != nil {
+		logrus.Error(err)
+		return
 	}
-}
 
-// Controls for AWS Foundational Security Best Practices v1.0.0
-func getFoundations100Controls(isGlobalCollectionRegion bool) []string {
-	controls := []string{}
-
-	if !isGlobalCollectionRegion {
-		controls = append(controls, []string{
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/Config.1",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.1",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.2",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.3",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.4",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.5",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.6",
-			"arn:aws:securityhub:%s:%s:control/aws-foundational-security-best-practices/v/1.0.0/IAM.7",
-		}...)
+	if consolidated {
+		hub.disableSecurityControls(scope, controls)
+		return
 	}
 
-	return controls
-}
+	for _, control := range controls {
+		if control.Standard == "" {
+			logrus.Errorf("    security control %s can only be disabled with consolidated control findings turned on", control.SecurityControlID)
+			continue
+		}
 
-// Controls for CIS AWS Foundations Benchmark v1.2.0
-func getCIS120Controls(isGlobalCollectionRegion bool, isCloudTrailAccount bool) []string {
-	controls := []string{}
-
-	if !isGlobalCollectionRegion {
-		controls = append(controls, []string{
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.2",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.3",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.4",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.5",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.6",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.7",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.8",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.9",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.10",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.11",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.12",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.13",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.14",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.16",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.20",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.22",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/2.5",
-		}...)
-	}
+		currentControl := control.arn(scope.Region, scope.AccountID)
+		reason, err := control.disabledReason(scope)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
 
-	if !isCloudTrailAccount || (isCloudTrailAccount && !isGlobalCollectionRegion) {
-		controls = append(controls, []string{
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/1.1",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/2.7",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.1",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.2",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.3",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.4",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.5",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.6",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.7",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.8",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.9",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.10",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.11",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.12",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.13",
-			"arn:aws:securityhub:%s:%s:control/cis-aws-foundations-benchmark/v/1.2.0/3.14",
-		}...)
-	}
+		logrus.Infof("    disabling control %s", currentControl)
+		err = hub.disableControl(currentControl, reason)
 
-	return controls
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
 }
 
 func getSecurityHubClient(region string) *securityhub.SecurityHub {
@@ -224,7 +238,12 @@ func logSecurityHubMemberAccounts(memberAccounts []string) {
 }
 
 // EnableSecurityHubAdministratorAccount enables the Security Hub Administrator account within the AWS Organization
-func EnableSecurityHubAdministratorAccount(region string, administratorAccountRole string, rootRole string) error {
+func EnableSecurityHubAdministratorAccount(region string, administratorAccountRole string, rootRole string, reconcile bool, externalAccountsFile string) error {
+	externalAccountIDs, err := readExternalAccountIDs(externalAccountsFile)
+	if err != nil {
+		return err
+	}
+
 	rootSession := GetSession()
 	rootAccountID := GetAccountIDWithRole(rootSession, rootRole)
 
@@ -240,6 +259,8 @@ func EnableSecurityHubAdministratorAccount(region string, administratorAccountRo
 	memberAccounts := ListMemberAccountIDs(rootRole)
 	logSecurityHubMemberAccounts(memberAccounts)
 
+	rows := make([][]string, 0)
+	failed := 0
 	for r := range enabledRegions {
 		currentRegion := enabledRegions[r]
 		logrus.Infof("  Processing region %s", currentRegion)
@@ -260,11 +281,18 @@ func EnableSecurityHubAdministratorAccount(region string, administratorAccountRo
 			logrus.Infof("    Account %s is already set as AWS Security Hub Administrator Account, skipping configuration", adminAccountID)
 		}
 
-		hub.addSecurityHubMemberAccounts(memberAccounts, adminAccountID)
+		results := hub.addSecurityHubMemberAccounts(memberAccounts, adminAccountID)
+		regionRows, regionFailed := memberResultRows(currentRegion, results)
+		rows = append(rows, regionRows...)
+		failed += regionFailed
+
+		if reconcile {
+			hub.removeStaleSecurityHubMemberAccounts(memberAccounts, externalAccountIDs)
+		}
 	}
 	logrus.Infof("Organization-wide AWS Security Hub complete")
 
-	return nil
+	return writeMemberResults(rows, failed)
 }
 
 func validateRegion(enabledRegions []string, region string) bool {
@@ -278,61 +306,29 @@ func validateRegion(enabledRegions []string, region string) bool {
 
 // DisableSecurityHubGlobalResourceControls disables Security Hub controls related to Global Resources in regions that
 // aren't collecting Global Resources. It also disables CloudTrail related controls in accounts that aren't the central
-// CloudTrail account.
+// CloudTrail account. The controls and the conditions under which they are disabled come from the embedded control
+// catalog, merged with catalogFile when one is given. Catalog conditions of the form tag:<name> hold when <name> is
+// one of conditionTags.
 //
 // https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-standards-cis-to-disable.html
 // https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-standards-fsbp-to-disable.html
-func DisableSecurityHubGlobalResourceControls(globalCollectionRegion string, role string, isPrivileged bool, isCloudTrailAccount bool) error {
-	if role == "" && !isPrivileged {
-		return errors.New("Either role must be provided or the privileged flag must be set")
-	}
-
-	session := GetSession()
-	var accountID string
-
-	if isPrivileged {
-		accountID = GetAccountID(session)
-	} else {
-		accountID = GetAccountIDWithRole(session, role)
-	}
-
-	enabledRegions := GetEnabledRegions("us-east-1", role, isPrivileged)
-
-	if !validateRegion(enabledRegions, globalCollectionRegion) {
-		return fmt.Errorf("%s is not a valid enabled region in this account", globalCollectionRegion)
+func DisableSecurityHubGlobalResourceControls(globalCollectionRegion string, role string, isPrivileged bool, isCloudTrailAccount bool, catalogFile string, conditionTags []string) error {
+	rules, err := newSecurityHubControlRules(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount, catalogFile, conditionTags)
+	if err != nil {
+		return err
 	}
 
-	logrus.Infof("Disabling Global Resource controls for all regions excluding %s for account %s", globalCollectionRegion, accountID)
-
-	for r := range enabledRegions {
-		currentRegion := enabledRegions[r]
-
-		var currentAccountClient *securityhub.SecurityHub
+	logrus.Infof("Disabling Global Resource controls for all regions excluding %s for account %s", globalCollectionRegion, rules.account.accountID)
 
-		if isPrivileged {
-			currentAccountClient = getSecurityHubClient(currentRegion)
-		} else {
-			currentAccountClient = getSecurityHubClientWithRole(currentRegion, role)
+	rules.forEachRegion(func(region string, hub SecurityHub, ctx securityHubControlContext) {
+		scope := securityHubControlScope{
+			Region:                 region,
+			AccountID:              rules.account.accountID,
+			GlobalCollectionRegion: globalCollectionRegion,
 		}
 
-		hub := SecurityHub{
-			currentAccountClient: currentAccountClient,
-		}
-
-		isGlobalCollectionRegion := currentRegion == globalCollectionRegion
-
-		if isGlobalCollectionRegion {
-			logrus.Infof("  processing global collection region %s", currentRegion)
-		} else {
-			logrus.Infof("  processing region %s", currentRegion)
-		}
-
-		foundations100Controls := getFoundations100Controls(isGlobalCollectionRegion)
-		cis120Controls := getCIS120Controls(isGlobalCollectionRegion, isCloudTrailAccount)
-
-		hub.disableControls(currentRegion, accountID, foundations100Controls)
-		hub.disableControls(currentRegion, accountID, cis120Controls)
-	}
+		hub.disableControls(scope, rules.catalog.controlsToDisable(ctx))
+	})
 
 	return nil
 }
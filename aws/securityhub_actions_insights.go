@@ -0,0 +1,395 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudposse/turf/common"
+)
+
+var securityHubActionTargetIDPattern = regexp.MustCompile(`^[a-zA-Z0-9]{1,20}$`)
+
+// SecurityHubActionTarget describes a custom action. The ID is part of the ARN that EventBridge rules match, so
+// changing it replaces the custom action.
+type SecurityHubActionTarget struct {
+	ID          string `yaml:"id"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// SecurityHubInsight describes a custom insight. Filters use the field names of the Security Hub API, e.g.
+// `SeverityLabel: [{Value: CRITICAL, Comparison: EQUALS}]`.
+type SecurityHubInsight struct {
+	Name    string                 `yaml:"name"`
+	GroupBy string                 `yaml:"groupBy"`
+	Filters map[string]interface{} `yaml:"filters"`
+}
+
+// SecurityHubActionsAndInsights is the format of the file read by SyncSecurityHubActionsAndInsights
+type SecurityHubActionsAndInsights struct {
+	Actions  []SecurityHubActionTarget `yaml:"actions"`
+	Insights []SecurityHubInsight      `yaml:"insights"`
+}
+
+// securityHubInsightState is an insight with its filters converted to the SDK type
+type securityHubInsightState struct {
+	name    string
+	groupBy string
+	filters *securityhub.AwsSecurityFindingFilters
+}
+
+func (action SecurityHubActionTarget) validate() error {
+	if action.Name == "" || action.Description == "" {
+		return fmt.Errorf("custom action %s must have a name and a description", action.ID)
+	}
+
+	if !securityHubActionTargetIDPattern.MatchString(action.ID) {
+		return fmt.Errorf("custom action %s has invalid id %q, must be 1 to 20 alphanumeric characters", action.Name, action.ID)
+	}
+
+	if len(action.Name) > 20 {
+		return fmt.Errorf("custom action %s has a name longer than 20 characters", action.Name)
+	}
+
+	return nil
+}
+
+func (insight SecurityHubInsight) state() (securityHubInsightState, error) {
+	state := securityHubInsightState{
+		name:    insight.Name,
+		groupBy: insight.GroupBy,
+		filters: &securityhub.AwsSecurityFindingFilters{},
+	}
+
+	if insight.Name == "" || insight.GroupBy == "" {
+		return state, fmt.Errorf("insight %s must have a name and a groupBy attribute", insight.Name)
+	}
+
+	if err := decodeSecurityHubValue(insight.Filters, state.filters); err != nil {
+		return state, fmt.Errorf("insight %s has invalid filters: %v", insight.Name, err)
+	}
+
+	return state, nil
+}
+
+func (state securityHubInsightState) equals(insight *securityhub.Insight) bool {
+	filters := insight.Filters
+	if filters == nil {
+		filters = &securityhub.AwsSecurityFindingFilters{}
+	}
+
+	return aws.StringValue(insight.GroupByAttribute) == state.groupBy &&
+		securityHubValueJSON(filters) == securityHubValueJSON(state.filters)
+}
+
+// securityHubActionTargetID returns the ID of a custom action from its ARN
+func securityHubActionTargetID(actionTargetArn string) string {
+	return actionTargetArn[strings.LastIndex(actionTargetArn, "/")+1:]
+}
+
+func readSecurityHubActionsAndInsights(path string) ([]SecurityHubActionTarget, []securityHubInsightState, error) {
+	definitions := SecurityHubActionsAndInsights{}
+	if err := common.ReadYAMLFile(path, &definitions); err != nil {
+		return nil, nil, err
+	}
+
+	names := make(map[string]bool)
+	ids := make(map[string]bool)
+	for _, action := range definitions.Actions {
+		if err := action.validate(); err != nil {
+			return nil, nil, err
+		}
+		if names[action.Name] || ids[action.ID] {
+			return nil, nil, fmt.Errorf("custom action %s is defined more than once", action.Name)
+		}
+		names[action.Name] = true
+		ids[action.ID] = true
+	}
+
+	names = make(map[string]bool)
+	insights := make([]securityHubInsightState, 0, len(definitions.Insights))
+	for _, definition := range definitions.Insights {
+		insight, err := definition.state()
+		if err != nil {
+			return nil, nil, err
+		}
+		if names[insight.name] {
+			return nil, nil, fmt.Errorf("insight %s is defined more than once", insight.name)
+		}
+		names[insight.name] = true
+
+		insights = append(insights, insight)
+	}
+
+	return definitions.Actions, insights, nil
+}
+
+// getActionTargets returns the custom actions of the account, keyed by ID
+func (hub SecurityHub) getActionTargets() (map[string]*securityhub.ActionTarget, error) {
+	actions := make(map[string]*securityhub.ActionTarget)
+	err := hub.currentAccountClient.DescribeActionTargetsPages(&securityhub.DescribeActionTargetsInput{}, func(page *securityhub.DescribeActionTargetsOutput, lastPage bool) bool {
+		for _, action := range page.ActionTargets {
+			actions[securityHubActionTargetID(aws.StringValue(action.ActionTargetArn))] = action
+		}
+		return true
+	})
+	return actions, err
+}
+
+// getInsights returns the custom insights of the account, keyed by name. Managed insights aren't included.
+func (hub SecurityHub) getInsights() (map[string]*securityhub.Insight, error) {
+	insights := make(map[string]*securityhub.Insight)
+	err := hub.currentAccountClient.GetInsightsPages(&securityhub.GetInsightsInput{}, func(page *securityhub.GetInsightsOutput, lastPage bool) bool {
+		for _, insight := range page.Insights {
+			if strings.Contains(aws.StringValue(insight.InsightArn), ":insight/securityhub/default/") {
+				continue
+			}
+			insights[aws.StringValue(insight.Name)] = insight
+		}
+		return true
+	})
+	return insights, err
+}
+
+func (hub SecurityHub) createActionTarget(action SecurityHubActionTarget) error {
+	_, err := hub.currentAccountClient.CreateActionTarget(&securityhub.CreateActionTargetInput{
+		Description: aws.String(action.Description),
+		Id:          aws.String(action.ID),
+		Name:        aws.String(action.Name),
+	})
+	return err
+}
+
+func (hub SecurityHub) deleteActionTarget(actionTargetArn *string) error {
+	_, err := hub.currentAccountClient.DeleteActionTarget(&securityhub.DeleteActionTargetInput{ActionTargetArn: actionTargetArn})
+	return err
+}
+
+// syncActionTargets plans the changes needed to make the custom actions match the definitions and, when apply is set,
+// makes them. Custom actions are matched by ID, so that a renamed action keeps its ARN, and then by name, in which case
+// the action's ID changed and it is deleted and created again.
+func (hub SecurityHub) syncActionTargets(actions []SecurityHubActionTarget, prune bool, apply bool) error {
+	existing, err := hub.getActionTargets()
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*securityhub.ActionTarget)
+	for _, action := range existing {
+		byName[aws.StringValue(action.Name)] = action
+	}
+
+	// Actions matched by ID are claimed first, so that they can't also be matched by the name of another definition
+	matched := make(map[string]bool)
+	for _, action := range actions {
+		if current, found := existing[action.ID]; found {
+			matched[aws.StringValue(current.ActionTargetArn)] = true
+		}
+	}
+
+	for _, action := range actions {
+		var err error
+		current, foundByID := existing[action.ID]
+		if !foundByID {
+			current = byName[action.Name]
+			if current != nil && matched[aws.StringValue(current.ActionTargetArn)] {
+				current = nil
+			}
+		}
+
+		switch {
+		case current == nil:
+			logrus.Infof("    + create custom action %s (%s)", action.Name, action.ID)
+			if apply {
+				err = hub.createActionTarget(action)
+			}
+		case !foundByID:
+			matched[aws.StringValue(current.ActionTargetArn)] = true
+			logrus.Infof("    -/+ replace custom action %s (%s -> %s)", action.Name, securityHubActionTargetID(aws.StringValue(current.ActionTargetArn)), action.ID)
+			if apply {
+				err = hub.deleteActionTarget(current.ActionTargetArn)
+				if err == nil {
+					err = hub.createActionTarget(action)
+				}
+			}
+		case aws.StringValue(current.Name) != action.Name || aws.StringValue(current.Description) != action.Description:
+			if aws.StringValue(current.Name) != action.Name {
+				logrus.Infof("    ~ update custom action %s (%s), renamed from %s", action.Name, action.ID, aws.StringValue(current.Name))
+			} else {
+				logrus.Infof("    ~ update custom action %s (%s)", action.Name, action.ID)
+			}
+			if apply {
+				_, err = hub.currentAccountClient.UpdateActionTarget(&securityhub.UpdateActionTargetInput{
+					ActionTargetArn: current.ActionTargetArn,
+					Description:     aws.String(action.Description),
+					Name:            aws.String(action.Name),
+				})
+			}
+		default:
+			logrus.Infof("    = custom action %s is up to date", action.Name)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	ids := make([]string, 0, len(existing))
+	for id := range existing {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if matched[aws.StringValue(existing[id].ActionTargetArn)] {
+			continue
+		}
+
+		name := aws.StringValue(existing[id].Name)
+		if !prune {
+			logrus.Infof("    ! custom action %s (%s) is not defined, run with --prune to delete it", name, id)
+			continue
+		}
+
+		logrus.Infof("    - delete custom action %s (%s)", name, id)
+		if apply {
+			if err := hub.deleteActionTarget(existing[id].ActionTargetArn); err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// syncInsights plans the changes needed to make the custom insights match the definitions and, when apply is set,
+// makes them
+func (hub SecurityHub) syncInsights(insights []securityHubInsightState, prune bool, apply bool) error {
+	existing, err := hub.getInsights()
+	if err != nil {
+		return err
+	}
+
+	defined := make(map[string]bool)
+	for _, insight := range insights {
+		defined[insight.name] = true
+
+		var err error
+		current, found := existing[insight.name]
+		switch {
+		case !found:
+			logrus.Infof("    + create insight %s (grouped by %s)", insight.name, insight.groupBy)
+			if apply {
+				_, err = hub.currentAccountClient.CreateInsight(&securityhub.CreateInsightInput{
+					Filters:          insight.filters,
+					GroupByAttribute: aws.String(insight.groupBy),
+					Name:             aws.String(insight.name),
+				})
+			}
+		case !insight.equals(current):
+			logrus.Infof("    ~ update insight %s (grouped by %s)", insight.name, insight.groupBy)
+			if apply {
+				_, err = hub.currentAccountClient.UpdateInsight(&securityhub.UpdateInsightInput{
+					Filters:          insight.filters,
+					GroupByAttribute: aws.String(insight.groupBy),
+					InsightArn:       current.InsightArn,
+					Name:             aws.String(insight.name),
+				})
+			}
+		default:
+			logrus.Infof("    = insight %s is up to date", insight.name)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if defined[name] {
+			continue
+		}
+
+		if !prune {
+			logrus.Infof("    ! insight %s is not defined, run with --prune to delete it", name)
+			continue
+		}
+
+		logrus.Infof("    - delete insight %s", name)
+		if apply {
+			if _, err := hub.currentAccountClient.DeleteInsight(&securityhub.DeleteInsightInput{InsightArn: existing[name].InsightArn}); err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SyncSecurityHubActionsAndInsights makes the custom actions and insights of the Security Hub Administrator Account
+// match the definitions in a YAML file, in each of the selected regions (all enabled regions when none are selected).
+// Custom actions and insights are matched by name. The planned changes are always shown and are only made when apply
+// is set. Those that aren't defined are only deleted when prune is set.
+func SyncSecurityHubActionsAndInsights(region string, administratorAccountRole string, regions []string, path string, prune bool, apply bool) error {
+	actions, insights, err := readSecurityHubActionsAndInsights(path)
+	if err != nil {
+		return err
+	}
+
+	selectedRegions, err := SelectRegions(GetEnabledRegions(region, administratorAccountRole, false), regions)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Syncing %d AWS Security Hub custom actions and %d insights from %s", len(actions), len(insights), path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	for _, currentRegion := range selectedRegions {
+		logrus.Infof("  Processing region %s", currentRegion)
+
+		hub := SecurityHub{currentAccountClient: getSecurityHubClientWithRole(currentRegion, administratorAccountRole)}
+
+		if err := hub.syncActionTargets(actions, prune, apply); err != nil {
+			logrus.Error(err)
+		}
+
+		if err := hub.syncInsights(insights, prune, apply); err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	logrus.Info("AWS Security Hub custom actions and insights sync complete")
+
+	return nil
+}
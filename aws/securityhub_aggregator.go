@@ -0,0 +1,177 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+)
+
+// Region linking modes of a Security Hub finding aggregator
+const (
+	SecurityHubLinkAllRegions                = "ALL_REGIONS"
+	SecurityHubLinkAllRegionsExceptSpecified = "ALL_REGIONS_EXCEPT_SPECIFIED"
+	SecurityHubLinkSpecifiedRegions          = "SPECIFIED_REGIONS"
+)
+
+func validateSecurityHubLinking(enabledRegions []string, homeRegion string, linkingMode string, regions []string) error {
+	switch linkingMode {
+	case SecurityHubLinkAllRegions:
+		if len(regions) > 0 {
+			return fmt.Errorf("Regions can't be specified with %s linking", SecurityHubLinkAllRegions)
+		}
+	case SecurityHubLinkAllRegionsExceptSpecified, SecurityHubLinkSpecifiedRegions:
+		if len(regions) == 0 {
+			return fmt.Errorf("Regions must be specified with %s linking", linkingMode)
+		}
+	default:
+		return fmt.Errorf("%s is not a valid linking mode, expected %s, %s or %s", linkingMode, SecurityHubLinkAllRegions,
+			SecurityHubLinkAllRegionsExceptSpecified, SecurityHubLinkSpecifiedRegions)
+	}
+
+	if !validateRegion(enabledRegions, homeRegion) {
+		return fmt.Errorf("%s is not a valid enabled region in this account", homeRegion)
+	}
+
+	for _, region := range regions {
+		if region == homeRegion {
+			return fmt.Errorf("The home region %s can't be one of the specified regions", homeRegion)
+		}
+		if !validateRegion(enabledRegions, region) {
+			return fmt.Errorf("%s is not a valid enabled region in this account", region)
+		}
+	}
+
+	return nil
+}
+
+// linkedSecurityHubRegions returns the regions whose findings are aggregated into the home region
+func linkedSecurityHubRegions(enabledRegions []string, homeRegion string, linkingMode string, regions []string) []string {
+	specified := map[string]bool{}
+	for _, region := range regions {
+		specified[region] = true
+	}
+
+	linked := []string{}
+	for _, region := range enabledRegions {
+		if region == homeRegion {
+			continue
+		}
+
+		switch linkingMode {
+		case SecurityHubLinkAllRegions:
+			linked = append(linked, region)
+		case SecurityHubLinkAllRegionsExceptSpecified:
+			if !specified[region] {
+				linked = append(linked, region)
+			}
+		case SecurityHubLinkSpecifiedRegions:
+			if specified[region] {
+				linked = append(linked, region)
+			}
+		}
+	}
+
+	sort.Strings(linked)
+	return linked
+}
+
+func (hub SecurityHub) getFindingAggregator() (*securityhub.GetFindingAggregatorOutput, error) {
+	aggregators, err := hub.adminAccountClient.ListFindingAggregators(&securityhub.ListFindingAggregatorsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(aggregators.FindingAggregators) == 0 {
+		return nil, nil
+	}
+
+	return hub.adminAccountClient.GetFindingAggregator(&securityhub.GetFindingAggregatorInput{
+		FindingAggregatorArn: aggregators.FindingAggregators[0].FindingAggregatorArn,
+	})
+}
+
+// SetSecurityHubFindingAggregator creates or updates the finding aggregator of the Security Hub Administrator Account
+// in homeRegion, linking the regions selected by linkingMode and regions. An aggregator that is already up to date is
+// left alone. The regions whose findings are aggregated are logged.
+func SetSecurityHubFindingAggregator(homeRegion string, administratorAccountRole string, linkingMode string, regions []string) error {
+	enabledRegions := GetEnabledRegions(homeRegion, administratorAccountRole, false)
+
+	if err := validateSecurityHubLinking(enabledRegions, homeRegion, linkingMode, regions); err != nil {
+		return err
+	}
+
+	hub := SecurityHub{adminAccountClient: getSecurityHubClientWithRole(homeRegion, administratorAccountRole)}
+
+	aggregator, err := hub.getFindingAggregator()
+	if err != nil {
+		return err
+	}
+
+	var sortedRegions []string
+	if len(regions) > 0 {
+		sortedRegions = append(sortedRegions, regions...)
+		sort.Strings(sortedRegions)
+	}
+
+	if aggregator == nil {
+		logrus.Infof("Creating finding aggregator in %s with %s linking", homeRegion, linkingMode)
+
+		_, err := hub.adminAccountClient.CreateFindingAggregator(&securityhub.CreateFindingAggregatorInput{
+			RegionLinkingMode: aws.String(linkingMode),
+			Regions:           aws.StringSlice(sortedRegions),
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		aggregationRegion := aws.StringValue(aggregator.FindingAggregationRegion)
+		if aggregationRegion != homeRegion {
+			return fmt.Errorf("The finding aggregator is in %s, delete it there before moving it to %s", aggregationRegion, homeRegion)
+		}
+
+		currentRegions := aws.StringValueSlice(aggregator.Regions)
+		sort.Strings(currentRegions)
+
+		if aws.StringValue(aggregator.RegionLinkingMode) == linkingMode && strings.Join(currentRegions, ",") == strings.Join(sortedRegions, ",") {
+			logrus.Infof("Finding aggregator in %s is up to date", homeRegion)
+		} else {
+			logrus.Infof("Updating finding aggregator in %s from %s to %s linking", homeRegion, aws.StringValue(aggregator.RegionLinkingMode), linkingMode)
+
+			_, err := hub.adminAccountClient.UpdateFindingAggregator(&securityhub.UpdateFindingAggregatorInput{
+				FindingAggregatorArn: aggregator.FindingAggregatorArn,
+				RegionLinkingMode:    aws.String(linkingMode),
+				Regions:              aws.StringSlice(sortedRegions),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	logrus.Infof("  Findings are aggregated into %s from:", homeRegion)
+	for _, region := range linkedSecurityHubRegions(enabledRegions, homeRegion, linkingMode, regions) {
+		logrus.Infof("    %s", region)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/cloudposse/turf/common"
+)
+
+// Security Hub filters, criteria and actions have dozens of fields. Rather than mirroring them, definitions use the
+// field names of the Security Hub API, which are also the names of the exported fields of the SDK types, and are
+// converted with encoding/json.
+
+// decodeSecurityHubValue converts a value read from YAML or JSON into target, an SDK type. Fields that the SDK type
+// doesn't have, or values it can't hold, are rejected rather than silently dropped.
+func decodeSecurityHubValue(v interface{}, target interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(common.JSONCompatible(v))
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return err
+	}
+
+	var given, decoded interface{}
+	if err := json.Unmarshal(data, &given); err != nil {
+		return err
+	}
+
+	decoded, err = encodeSecurityHubValue(target)
+	if err != nil {
+		return err
+	}
+
+	// encoding/json matches field names case-insensitively, so names are checked by comparing the round trip. Null
+	// values are the same as unset fields.
+	if field, found := securityHubValueDifference("", removeSecurityHubNulls(given), decoded); found {
+		return fmt.Errorf("%s is an unknown field or has an invalid value, fields must be named as in the Security Hub API", field)
+	}
+
+	return nil
+}
+
+// securityHubValueDifference returns the path of the first field that differs between the given and the decoded value
+func securityHubValueDifference(path string, given interface{}, decoded interface{}) (string, bool) {
+	switch value := given.(type) {
+	case map[string]interface{}:
+		other, ok := decoded.(map[string]interface{})
+		if !ok {
+			return securityHubValuePath(path), true
+		}
+
+		// Fields that were given are checked first, so that a misspelled field is named rather than the field it
+		// was decoded into
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		missing := make([]string, 0)
+		for key := range other {
+			if _, found := value[key]; !found {
+				missing = append(missing, key)
+			}
+		}
+		sort.Strings(missing)
+
+		for _, key := range append(keys, missing...) {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+
+			if difference, found := securityHubValueDifference(field, value[key], other[key]); found {
+				return difference, true
+			}
+		}
+
+		return "", false
+	case []interface{}:
+		other, ok := decoded.([]interface{})
+		if !ok || len(value) != len(other) {
+			return securityHubValuePath(path), true
+		}
+
+		for i := range value {
+			if difference, found := securityHubValueDifference(fmt.Sprintf("%s[%d]", path, i), value[i], other[i]); found {
+				return difference, true
+			}
+		}
+
+		return "", false
+	default:
+		if !reflect.DeepEqual(given, decoded) {
+			return securityHubValuePath(path), true
+		}
+
+		return "", false
+	}
+}
+
+func securityHubValuePath(path string) string {
+	if path == "" {
+		return "the value"
+	}
+	return path
+}
+
+// encodeSecurityHubValue converts an SDK type to a value that can be written as YAML or JSON, leaving out unset fields
+func encodeSecurityHubValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded interface{}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+
+	return removeSecurityHubNulls(encoded), nil
+}
+
+// removeSecurityHubNulls drops the null values that encoding/json writes for the unset fields of SDK types
+func removeSecurityHubNulls(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if field == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = removeSecurityHubNulls(field)
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = removeSecurityHubNulls(value[i])
+		}
+	}
+
+	return v
+}
+
+// securityHubValueJSON returns the compact JSON of an SDK type, used to compare and show values
+func securityHubValueJSON(v interface{}) string {
+	encoded, err := encodeSecurityHubValue(v)
+	if err != nil {
+		return err.Error()
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
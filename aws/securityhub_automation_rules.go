@@ -0,0 +1,539 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudposse/turf/common"
+)
+
+const securityHubAutomationRulesBatchSize = 100
+
+// SecurityHubAutomationRule describes a Security Hub automation rule. Criteria and actions use the field names of the
+// Security Hub API, e.g. `ProductName: [{Value: GuardDuty, Comparison: EQUALS}]`.
+type SecurityHubAutomationRule struct {
+	Name        string                   `yaml:"name"`
+	Description string                   `yaml:"description"`
+	Order       int64                    `yaml:"order"`
+	Status      string                   `yaml:"status,omitempty"`
+	IsTerminal  bool                     `yaml:"isTerminal,omitempty"`
+	Criteria    map[string]interface{}   `yaml:"criteria"`
+	Actions     []map[string]interface{} `yaml:"actions"`
+}
+
+// SecurityHubAutomationRules is the format of the file read by SyncSecurityHubAutomationRules and written by
+// ExportSecurityHubAutomationRules
+type SecurityHubAutomationRules struct {
+	Rules []SecurityHubAutomationRule `yaml:"rules"`
+}
+
+// securityHubAutomationRuleState is an automation rule with its criteria and actions converted to the SDK types
+type securityHubAutomationRuleState struct {
+	name        string
+	description string
+	order       int64
+	status      string
+	isTerminal  bool
+	criteria    *securityhub.AutomationRulesFindingFilters
+	actions     []*securityhub.AutomationRulesAction
+}
+
+func (rule SecurityHubAutomationRule) state() (securityHubAutomationRuleState, error) {
+	state := securityHubAutomationRuleState{
+		name:        rule.Name,
+		description: rule.Description,
+		order:       rule.Order,
+		status:      rule.Status,
+		isTerminal:  rule.IsTerminal,
+		criteria:    &securityhub.AutomationRulesFindingFilters{},
+	}
+
+	if state.status == "" {
+		state.status = securityhub.RuleStatusEnabled
+	}
+
+	if err := decodeSecurityHubValue(rule.Criteria, state.criteria); err != nil {
+		return state, fmt.Errorf("automation rule %s has invalid criteria: %v", rule.Name, err)
+	}
+
+	for i, action := range rule.Actions {
+		decoded := &securityhub.AutomationRulesAction{}
+		if err := decodeSecurityHubValue(action, decoded); err != nil {
+			return state, fmt.Errorf("automation rule %s has invalid action %d: %v", rule.Name, i+1, err)
+		}
+		state.actions = append(state.actions, decoded)
+	}
+
+	return state, nil
+}
+
+func (state securityHubAutomationRuleState) validate() error {
+	if state.name == "" {
+		return fmt.Errorf("automation rule name must be provided")
+	}
+
+	if state.status != securityhub.RuleStatusEnabled && state.status != securityhub.RuleStatusDisabled {
+		return fmt.Errorf("automation rule %s has invalid status %s, must be %s or %s", state.name, state.status, securityhub.RuleStatusEnabled, securityhub.RuleStatusDisabled)
+	}
+
+	if state.order < 1 || state.order > 1000 {
+		return fmt.Errorf("automation rule %s has invalid order %d, must be between 1 and 1000", state.name, state.order)
+	}
+
+	if err := state.createInput().Validate(); err != nil {
+		return fmt.Errorf("automation rule %s: %v", state.name, err)
+	}
+
+	return nil
+}
+
+func (state securityHubAutomationRuleState) createInput() *securityhub.CreateAutomationRuleInput {
+	return &securityhub.CreateAutomationRuleInput{
+		Actions:     state.actions,
+		Criteria:    state.criteria,
+		Description: aws.String(state.description),
+		IsTerminal:  aws.Bool(state.isTerminal),
+		RuleName:    aws.String(state.name),
+		RuleOrder:   aws.Int64(state.order),
+		RuleStatus:  aws.String(state.status),
+	}
+}
+
+func (state securityHubAutomationRuleState) updateItem(ruleArn *string) *securityhub.UpdateAutomationRulesRequestItem {
+	return &securityhub.UpdateAutomationRulesRequestItem{
+		Actions:     state.actions,
+		Criteria:    state.criteria,
+		Description: aws.String(state.description),
+		IsTerminal:  aws.Bool(state.isTerminal),
+		RuleArn:     ruleArn,
+		RuleName:    aws.String(state.name),
+		RuleOrder:   aws.Int64(state.order),
+		RuleStatus:  aws.String(state.status),
+	}
+}
+
+func securityHubAutomationRuleStateFromAPI(rule *securityhub.AutomationRulesConfig) securityHubAutomationRuleState {
+	state := securityHubAutomationRuleState{
+		name:        aws.StringValue(rule.RuleName),
+		description: aws.StringValue(rule.Description),
+		order:       aws.Int64Value(rule.RuleOrder),
+		status:      aws.StringValue(rule.RuleStatus),
+		isTerminal:  aws.BoolValue(rule.IsTerminal),
+		criteria:    rule.Criteria,
+		actions:     rule.Actions,
+	}
+
+	if state.criteria == nil {
+		state.criteria = &securityhub.AutomationRulesFindingFilters{}
+	}
+
+	return state
+}
+
+// definition converts the state back to the format of the file
+func (state securityHubAutomationRuleState) definition() (SecurityHubAutomationRule, error) {
+	rule := SecurityHubAutomationRule{
+		Name:        state.name,
+		Description: state.description,
+		Order:       state.order,
+		Status:      state.status,
+		IsTerminal:  state.isTerminal,
+	}
+
+	criteria, err := encodeSecurityHubValue(state.criteria)
+	if err != nil {
+		return rule, err
+	}
+	if criteria, ok := criteria.(map[string]interface{}); ok {
+		rule.Criteria = criteria
+	}
+
+	for _, action := range state.actions {
+		encoded, err := encodeSecurityHubValue(action)
+		if err != nil {
+			return rule, err
+		}
+		if encoded, ok := encoded.(map[string]interface{}); ok {
+			rule.Actions = append(rule.Actions, encoded)
+		}
+	}
+
+	return rule, nil
+}
+
+// diff returns the changes from current to state, one line per attribute, with criteria compared per finding field
+func (state securityHubAutomationRuleState) diff(current securityHubAutomationRuleState) []string {
+	changes := []string{}
+
+	if current.description != state.description {
+		changes = append(changes, fmt.Sprintf("description: %q -> %q", current.description, state.description))
+	}
+	if current.order != state.order {
+		changes = append(changes, fmt.Sprintf("order: %d -> %d", current.order, state.order))
+	}
+	if current.status != state.status {
+		changes = append(changes, fmt.Sprintf("status: %s -> %s", current.status, state.status))
+	}
+	if current.isTerminal != state.isTerminal {
+		changes = append(changes, fmt.Sprintf("isTerminal: %t -> %t", current.isTerminal, state.isTerminal))
+	}
+
+	currentCriteria := securityHubJSONFields(securityHubValueJSON(current.criteria))
+	desiredCriteria := securityHubJSONFields(securityHubValueJSON(state.criteria))
+
+	fields := []string{}
+	for field := range currentCriteria {
+		fields = append(fields, field)
+	}
+	for field := range desiredCriteria {
+		if _, found := currentCriteria[field]; !found {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if currentCriteria[field] != desiredCriteria[field] {
+			changes = append(changes, fmt.Sprintf("criteria.%s: %s -> %s", field, orNone(currentCriteria[field]), orNone(desiredCriteria[field])))
+		}
+	}
+
+	currentActions := current.actionsJSON()
+	desiredActions := state.actionsJSON()
+	if currentActions != desiredActions {
+		changes = append(changes, fmt.Sprintf("actions: %s -> %s", currentActions, desiredActions))
+	}
+
+	return changes
+}
+
+func (state securityHubAutomationRuleState) actionsJSON() string {
+	actions := make([]string, 0, len(state.actions))
+	for _, action := range state.actions {
+		actions = append(actions, securityHubValueJSON(action))
+	}
+	return "[" + strings.Join(actions, ",") + "]"
+}
+
+// securityHubJSONFields splits a JSON object into the compact JSON of each of its fields
+func securityHubJSONFields(data string) map[string]string {
+	raw := make(map[string]json.RawMessage)
+	fields := make(map[string]string)
+
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return fields
+	}
+
+	for field, value := range raw {
+		fields[field] = string(value)
+	}
+
+	return fields
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+func readSecurityHubAutomationRules(path string) ([]securityHubAutomationRuleState, error) {
+	definitions := SecurityHubAutomationRules{}
+	if err := common.ReadYAMLFile(path, &definitions); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	rules := make([]securityHubAutomationRuleState, 0, len(definitions.Rules))
+	for _, definition := range definitions.Rules {
+		rule, err := definition.state()
+		if err != nil {
+			return nil, err
+		}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		if names[rule.name] {
+			return nil, fmt.Errorf("automation rule %s is defined more than once", rule.name)
+		}
+		names[rule.name] = true
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// resolveAggregationRegion returns the region of the Security Hub Administrator Account's finding aggregator, or
+// region when there is no finding aggregator
+func resolveAggregationRegion(region string, administratorAccountRole string) (string, error) {
+	hub := SecurityHub{adminAccountClient: getSecurityHubClientWithRole(region, administratorAccountRole)}
+
+	aggregator, err := hub.getFindingAggregator()
+	if err != nil {
+		return "", err
+	}
+
+	if aggregator == nil {
+		logrus.Infof("No finding aggregator found, using region %s", region)
+		return region, nil
+	}
+
+	return aws.StringValue(aggregator.FindingAggregationRegion), nil
+}
+
+// getAutomationRules returns the automation rules of the account, keyed by name
+func (hub SecurityHub) getAutomationRules() (map[string]*securityhub.AutomationRulesConfig, error) {
+	arns := []*string{}
+	input := &securityhub.ListAutomationRulesInput{}
+	for {
+		page, err := hub.adminAccountClient.ListAutomationRules(input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range page.AutomationRulesMetadata {
+			arns = append(arns, rule.RuleArn)
+		}
+
+		if page.NextToken == nil {
+			break
+		}
+		input.NextToken = page.NextToken
+	}
+
+	rules := make(map[string]*securityhub.AutomationRulesConfig)
+	for start := 0; start < len(arns); start += securityHubAutomationRulesBatchSize {
+		end := start + securityHubAutomationRulesBatchSize
+		if end > len(arns) {
+			end = len(arns)
+		}
+
+		output, err := hub.adminAccountClient.BatchGetAutomationRules(&securityhub.BatchGetAutomationRulesInput{
+			AutomationRulesArns: arns[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rule := range output.Rules {
+			name := aws.StringValue(rule.RuleName)
+			if _, found := rules[name]; found {
+				logrus.Warnf("    more than one automation rule is named %s, only %s is managed", name, aws.StringValue(rules[name].RuleArn))
+				continue
+			}
+			rules[name] = rule
+		}
+
+		for _, unprocessed := range output.UnprocessedAutomationRules {
+			logrus.Errorf("    could not get automation rule %s: %s", aws.StringValue(unprocessed.RuleArn), aws.StringValue(unprocessed.ErrorMessage))
+		}
+	}
+
+	return rules, nil
+}
+
+func logUnprocessedAutomationRules(unprocessed []*securityhub.UnprocessedAutomationRule) {
+	for _, rule := range unprocessed {
+		logrus.Errorf("    could not update automation rule %s: %s", aws.StringValue(rule.RuleArn), aws.StringValue(rule.ErrorMessage))
+	}
+}
+
+func (hub SecurityHub) updateAutomationRules(items []*securityhub.UpdateAutomationRulesRequestItem) error {
+	for start := 0; start < len(items); start += securityHubAutomationRulesBatchSize {
+		end := start + securityHubAutomationRulesBatchSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		output, err := hub.adminAccountClient.BatchUpdateAutomationRules(&securityhub.BatchUpdateAutomationRulesInput{
+			UpdateAutomationRulesRequestItems: items[start:end],
+		})
+		if err != nil {
+			return err
+		}
+		logUnprocessedAutomationRules(output.UnprocessedAutomationRules)
+	}
+
+	return nil
+}
+
+func (hub SecurityHub) deleteAutomationRules(arns []*string) error {
+	for start := 0; start < len(arns); start += securityHubAutomationRulesBatchSize {
+		end := start + securityHubAutomationRulesBatchSize
+		if end > len(arns) {
+			end = len(arns)
+		}
+
+		output, err := hub.adminAccountClient.BatchDeleteAutomationRules(&securityhub.BatchDeleteAutomationRulesInput{
+			AutomationRulesArns: arns[start:end],
+		})
+		if err != nil {
+			return err
+		}
+		logUnprocessedAutomationRules(output.UnprocessedAutomationRules)
+	}
+
+	return nil
+}
+
+// SyncSecurityHubAutomationRules makes the automation rules of the Security Hub Administrator Account, in the region
+// of its finding aggregator, match the definitions in a YAML file. The planned changes are always shown, with the
+// attributes that change, and are only made when apply is set. Rules that aren't defined are only deleted when prune
+// is set.
+func SyncSecurityHubAutomationRules(region string, administratorAccountRole string, path string, prune bool, apply bool) error {
+	rules, err := readSecurityHubAutomationRules(path)
+	if err != nil {
+		return err
+	}
+
+	aggregationRegion, err := resolveAggregationRegion(region, administratorAccountRole)
+	if err != nil {
+		return err
+	}
+
+	hub := SecurityHub{adminAccountClient: getSecurityHubClientWithRole(aggregationRegion, administratorAccountRole)}
+
+	logrus.Infof("Syncing %d AWS Security Hub automation rules from %s", len(rules), path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	logrus.Infof("  Processing region %s", aggregationRegion)
+
+	existing, err := hub.getAutomationRules()
+	if err != nil {
+		return err
+	}
+
+	updates := []*securityhub.UpdateAutomationRulesRequestItem{}
+	for _, rule := range rules {
+		current, found := existing[rule.name]
+		if !found {
+			logrus.Infof("    + create automation rule %s (order %d, %s)", rule.name, rule.order, rule.status)
+			if apply {
+				if _, err := hub.adminAccountClient.CreateAutomationRule(rule.createInput()); err != nil {
+					logrus.Error(err)
+				}
+			}
+			continue
+		}
+
+		changes := rule.diff(securityHubAutomationRuleStateFromAPI(current))
+		if len(changes) == 0 {
+			logrus.Infof("    = automation rule %s is up to date", rule.name)
+			continue
+		}
+
+		logrus.Infof("    ~ update automation rule %s", rule.name)
+		for _, change := range changes {
+			logrus.Infof("        %s", change)
+		}
+		updates = append(updates, rule.updateItem(current.RuleArn))
+	}
+
+	defined := make(map[string]bool)
+	for _, rule := range rules {
+		defined[rule.name] = true
+	}
+
+	names := make([]string, 0, len(existing))
+	for name := range existing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deletes := []*string{}
+	for _, name := range names {
+		if defined[name] {
+			continue
+		}
+
+		if !prune {
+			logrus.Infof("    ! automation rule %s is not defined, run with --prune to delete it", name)
+			continue
+		}
+
+		logrus.Infof("    - delete automation rule %s", name)
+		deletes = append(deletes, existing[name].RuleArn)
+	}
+
+	if apply {
+		if err := hub.updateAutomationRules(updates); err != nil {
+			logrus.Error(err)
+		}
+		if err := hub.deleteAutomationRules(deletes); err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	logrus.Info("AWS Security Hub automation rules sync complete")
+
+	return nil
+}
+
+// ExportSecurityHubAutomationRules writes the automation rules of the Security Hub Administrator Account, in the
+// region of its finding aggregator, to stdout in the format read by SyncSecurityHubAutomationRules
+func ExportSecurityHubAutomationRules(region string, administratorAccountRole string) error {
+	aggregationRegion, err := resolveAggregationRegion(region, administratorAccountRole)
+	if err != nil {
+		return err
+	}
+
+	hub := SecurityHub{adminAccountClient: getSecurityHubClientWithRole(aggregationRegion, administratorAccountRole)}
+
+	existing, err := hub.getAutomationRules()
+	if err != nil {
+		return err
+	}
+
+	definitions := SecurityHubAutomationRules{Rules: []SecurityHubAutomationRule{}}
+	for _, rule := range existing {
+		definition, err := securityHubAutomationRuleStateFromAPI(rule).definition()
+		if err != nil {
+			return err
+		}
+		definitions.Rules = append(definitions.Rules, definition)
+	}
+
+	sort.Slice(definitions.Rules, func(i, j int) bool {
+		if definitions.Rules[i].Order != definitions.Rules[j].Order {
+			return definitions.Rules[i].Order < definitions.Rules[j].Order
+		}
+		return definitions.Rules[i].Name < definitions.Rules[j].Name
+	})
+
+	data, err := yaml.Marshal(definitions)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(data)
+	return err
+}
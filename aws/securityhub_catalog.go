@@ -0,0 +1,306 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"bytes"
+	_ "embed" // needed for the control catalog
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/cloudposse/turf/common"
+)
+
+const securityHubConditionNotGlobalRegion = "not-global-region"
+const securityHubConditionNotCloudTrailAccount = "not-cloudtrail-account"
+const securityHubConditionTagPrefix = "tag:"
+const securityHubDefaultDisabledReason = "Global Resources are not collected in this region"
+
+//go:embed securityhub_controls.yaml
+var securityHubControlCatalogYAML []byte
+
+// SecurityHubControl is an entry of the control catalog. The control is disabled when any of its conditions holds.
+// SecurityControlID is the consolidated control ID and defaults to Control. Reason is a template that can reference
+// {{.Region}}, {{.AccountID}} and {{.GlobalCollectionRegion}}.
+type SecurityHubControl struct {
+	Standard          string   `yaml:"standard"`
+	Version           string   `yaml:"version"`
+	Control           string   `yaml:"control"`
+	SecurityControlID string   `yaml:"securityControlId"`
+	Conditions        []string `yaml:"conditions"`
+	Reason            string   `yaml:"reason"`
+}
+
+// SecurityHubControlSelector selects catalog entries to remove. An empty Version matches every version and Control
+// may be a glob.
+type SecurityHubControlSelector struct {
+	Standard string `yaml:"standard"`
+	Version  string `yaml:"version"`
+	Control  string `yaml:"control"`
+}
+
+// SecurityHubControlCatalog is the control catalog. The same format is used by the override file, where Controls are
+// added to (or replace) the embedded entries and Remove drops embedded entries.
+type SecurityHubControlCatalog struct {
+	Controls []SecurityHubControl         `yaml:"controls"`
+	Remove   []SecurityHubControlSelector `yaml:"remove"`
+}
+
+// securityHubControlScope is the account and region a control is updated in, and the data available to reason
+// templates
+type securityHubControlScope struct {
+	Region                 string
+	AccountID              string
+	GlobalCollectionRegion string
+}
+
+type securityHubControlContext struct {
+	isGlobalCollectionRegion bool
+	isCloudTrailAccount      bool
+	tags                     map[string]bool
+}
+
+func (c SecurityHubControl) key() string {
+	if c.Standard == "" {
+		return c.SecurityControlID
+	}
+
+	return fmt.Sprintf("%s/v/%s/%s", c.Standard, c.Version, c.Control)
+}
+
+func (c SecurityHubControl) arn(region string, accountID string) string {
+	return fmt.Sprintf("arn:aws:securityhub:%s:%s:control/%s", region, accountID, c.key())
+}
+
+func (c SecurityHubControl) securityControlID() string {
+	if c.SecurityControlID != "" {
+		return c.SecurityControlID
+	}
+
+	return c.Control
+}
+
+func (c SecurityHubControl) reasonTemplate() (*template.Template, error) {
+	reason := c.Reason
+	if reason == "" {
+		reason = securityHubDefaultDisabledReason
+	}
+
+	return template.New(c.key()).Option("missingkey=error").Parse(reason)
+}
+
+// validateReason checks that the reason template parses and only references fields of securityHubControlScope
+func (c SecurityHubControl) validateReason() error {
+	tmpl, err := c.reasonTemplate()
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(ioutil.Discard, securityHubControlScope{})
+}
+
+// usesGlobalCollectionRegion reports whether the reason of the control depends on {{.GlobalCollectionRegion}}
+func (c SecurityHubControl) usesGlobalCollectionRegion() bool {
+	scope := securityHubControlScope{Region: "region", AccountID: "account"}
+	without, err := c.disabledReason(scope)
+	if err != nil {
+		return false
+	}
+
+	scope.GlobalCollectionRegion = "global-collection-region"
+	with, err := c.disabledReason(scope)
+	if err != nil {
+		return false
+	}
+
+	return without != with
+}
+
+// disabledReason renders the reason template of the control for the account and region it is disabled in
+func (c SecurityHubControl) disabledReason(scope securityHubControlScope) (string, error) {
+	tmpl, err := c.reasonTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	var reason bytes.Buffer
+	if err := tmpl.Execute(&reason, scope); err != nil {
+		return "", fmt.Errorf("could not render the reason of control %s: %v", c.key(), err)
+	}
+
+	return reason.String(), nil
+}
+
+func (c SecurityHubControl) validate() error {
+	if c.Standard == "" || c.Version == "" || c.Control == "" {
+		return fmt.Errorf("control %q must have a standard, version and control", c.key())
+	}
+
+	if err := c.validateReason(); err != nil {
+		return fmt.Errorf("control %s has an invalid reason: %v", c.key(), err)
+	}
+
+	for _, condition := range c.Conditions {
+		switch {
+		case condition == securityHubConditionNotGlobalRegion:
+		case condition == securityHubConditionNotCloudTrailAccount:
+		case strings.HasPrefix(condition, securityHubConditionTagPrefix) && len(condition) > len(securityHubConditionTagPrefix):
+		default:
+			return fmt.Errorf("control %s has an unknown condition %q, expected %s, %s or %s<name>", c.key(), condition,
+				securityHubConditionNotGlobalRegion, securityHubConditionNotCloudTrailAccount, securityHubConditionTagPrefix)
+		}
+	}
+
+	return nil
+}
+
+func (c SecurityHubControl) shouldDisable(ctx securityHubControlContext) bool {
+	if len(c.Conditions) == 0 {
+		return true
+	}
+
+	for _, condition := range c.Conditions {
+		switch condition {
+		case securityHubConditionNotGlobalRegion:
+			if !ctx.isGlobalCollectionRegion {
+				return true
+			}
+		case securityHubConditionNotCloudTrailAccount:
+			if !ctx.isCloudTrailAccount {
+				return true
+			}
+		default:
+			if ctx.tags[strings.TrimPrefix(condition, securityHubConditionTagPrefix)] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s SecurityHubControlSelector) matches(c SecurityHubControl) bool {
+	return s.Standard == c.Standard &&
+		(s.Version == "" || s.Version == c.Version) &&
+		common.MatchGlob(s.Control, c.Control)
+}
+
+// merge applies an override catalog: removals are applied first, then each control replaces the entry with the same
+// standard, version and control or is appended
+func (catalog SecurityHubControlCatalog) merge(override SecurityHubControlCatalog) SecurityHubControlCatalog {
+	controls := []SecurityHubControl{}
+
+	for _, control := range catalog.Controls {
+		removed := false
+		for _, selector := range override.Remove {
+			if selector.matches(control) {
+				removed = true
+				break
+			}
+		}
+
+		if !removed {
+			controls = append(controls, control)
+		}
+	}
+
+	for _, control := range override.Controls {
+		replaced := false
+		for i := range controls {
+			if controls[i].key() == control.key() {
+				controls[i] = control
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			controls = append(controls, control)
+		}
+	}
+
+	return SecurityHubControlCatalog{Controls: controls}
+}
+
+func (catalog SecurityHubControlCatalog) validate() error {
+	for _, control := range catalog.Controls {
+		if err := control.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, selector := range catalog.Remove {
+		if selector.Standard == "" || selector.Control == "" {
+			return fmt.Errorf("entries to remove must have a standard and control")
+		}
+	}
+
+	return nil
+}
+
+// controlsToDisable returns the controls of the catalog whose conditions hold in ctx
+func (catalog SecurityHubControlCatalog) controlsToDisable(ctx securityHubControlContext) []SecurityHubControl {
+	controls := []SecurityHubControl{}
+
+	for _, control := range catalog.Controls {
+		if control.shouldDisable(ctx) {
+			controls = append(controls, control)
+		}
+	}
+
+	return controls
+}
+
+// controlsToEnable returns the controls of the catalog whose conditions don't hold in ctx
+func (catalog SecurityHubControlCatalog) controlsToEnable(ctx securityHubControlContext) []SecurityHubControl {
+	controls := []SecurityHubControl{}
+
+	for _, control := range catalog.Controls {
+		if !control.shouldDisable(ctx) {
+			controls = append(controls, control)
+		}
+	}
+
+	return controls
+}
+
+// LoadSecurityHubControlCatalog returns the embedded control catalog merged with the override file, if one is given
+func LoadSecurityHubControlCatalog(overridePath string) (SecurityHubControlCatalog, error) {
+	catalog := SecurityHubControlCatalog{}
+	if err := yaml.UnmarshalStrict(securityHubControlCatalogYAML, &catalog); err != nil {
+		return catalog, fmt.Errorf("could not read the embedded control catalog: %v", err)
+	}
+
+	if overridePath == "" {
+		return catalog, catalog.validate()
+	}
+
+	override := SecurityHubControlCatalog{}
+	if err := common.ReadYAMLFile(overridePath, &override); err != nil {
+		return catalog, fmt.Errorf("could not read %s: %v", overridePath, err)
+	}
+
+	if err := override.validate(); err != nil {
+		return catalog, fmt.Errorf("%s: %v", overridePath, err)
+	}
+
+	catalog = catalog.merge(override)
+	return catalog, catalog.validate()
+}
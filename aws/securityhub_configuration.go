@@ -0,0 +1,732 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+)
+
+// SecurityHubSelfManagedPolicy can be associated like a configuration policy to let targets manage Security Hub
+// themselves
+const SecurityHubSelfManagedPolicy = "SELF_MANAGED_SECURITY_HUB"
+
+// How often and for how long configuration policy associations are checked while waiting for them to be removed
+const securityHubConfigurationPollInterval = 5 * time.Second
+const securityHubConfigurationTimeout = 5 * time.Minute
+
+var securityHubAccountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// SecurityHubParameterValue is the value of a security control parameter. Exactly one field must be set, matching
+// the type of the parameter.
+type SecurityHubParameterValue struct {
+	Boolean     *bool    `yaml:"boolean,omitempty"`
+	Double      *float64 `yaml:"double,omitempty"`
+	Enum        *string  `yaml:"enum,omitempty"`
+	EnumList    []string `yaml:"enumList,omitempty"`
+	Integer     *int64   `yaml:"integer,omitempty"`
+	IntegerList []int64  `yaml:"integerList,omitempty"`
+	String      *string  `yaml:"string,omitempty"`
+	StringList  []string `yaml:"stringList,omitempty"`
+}
+
+// SecurityHubConfigurationPolicy describes a Security Hub configuration policy. Standards may be given as aliases or
+// ARNs, controls as security control IDs, and control parameters are keyed by security control ID then parameter
+// name. Every control that isn't disabled is enabled.
+type SecurityHubConfigurationPolicy struct {
+	Name              string                                          `yaml:"name"`
+	Description       string                                          `yaml:"description,omitempty"`
+	ServiceEnabled    *bool                                           `yaml:"serviceEnabled,omitempty"`
+	Standards         []string                                        `yaml:"standards,omitempty"`
+	DisabledControls  []string                                        `yaml:"disabledControls,omitempty"`
+	ControlParameters map[string]map[string]SecurityHubParameterValue `yaml:"controlParameters,omitempty"`
+}
+
+// SecurityHubConfigurationAssociation associates a configuration policy, or SELF_MANAGED_SECURITY_HUB, with targets
+// given as a root ID (r-), an Organizational Unit ID (ou-) or an account ID
+type SecurityHubConfigurationAssociation struct {
+	Policy  string   `yaml:"policy"`
+	Targets []string `yaml:"targets"`
+}
+
+// SecurityHubConfigurationDefinitions is the format of the file read by SyncSecurityHubConfiguration
+type SecurityHubConfigurationDefinitions struct {
+	Policies     []SecurityHubConfigurationPolicy      `yaml:"policies"`
+	Associations []SecurityHubConfigurationAssociation `yaml:"associations"`
+}
+
+// securityHubPolicyState is the comparable state of a configuration policy, with standards resolved to ARNs
+type securityHubPolicyState struct {
+	Description      string
+	ServiceEnabled   bool
+	Standards        []string
+	EnabledControls  []string
+	DisabledControls []string
+	Parameters       map[string]map[string]SecurityHubParameterValue
+}
+
+func (value SecurityHubParameterValue) count() int {
+	count := 0
+	for _, set := range []bool{value.Boolean != nil, value.Double != nil, value.Enum != nil, value.EnumList != nil,
+		value.Integer != nil, value.IntegerList != nil, value.String != nil, value.StringList != nil} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+func (value SecurityHubParameterValue) toAPI() *securityhub.ParameterValue {
+	parameterValue := &securityhub.ParameterValue{
+		Boolean: value.Boolean,
+		Double:  value.Double,
+		Enum:    value.Enum,
+		Integer: value.Integer,
+		String_: value.String,
+	}
+
+	if value.EnumList != nil {
+		parameterValue.EnumList = aws.StringSlice(value.EnumList)
+	}
+	if value.IntegerList != nil {
+		parameterValue.IntegerList = aws.Int64Slice(value.IntegerList)
+	}
+	if value.StringList != nil {
+		parameterValue.StringList = aws.StringSlice(value.StringList)
+	}
+
+	return parameterValue
+}
+
+func securityHubParameterValueFromAPI(value *securityhub.ParameterValue) SecurityHubParameterValue {
+	parameterValue := SecurityHubParameterValue{
+		Boolean: value.Boolean,
+		Double:  value.Double,
+		Enum:    value.Enum,
+		Integer: value.Integer,
+		String:  value.String_,
+	}
+
+	if value.EnumList != nil {
+		parameterValue.EnumList = aws.StringValueSlice(value.EnumList)
+	}
+	if value.IntegerList != nil {
+		parameterValue.IntegerList = aws.Int64ValueSlice(value.IntegerList)
+	}
+	if value.StringList != nil {
+		parameterValue.StringList = aws.StringValueSlice(value.StringList)
+	}
+
+	return parameterValue
+}
+
+func (policy SecurityHubConfigurationPolicy) isServiceEnabled() bool {
+	return policy.ServiceEnabled == nil || *policy.ServiceEnabled
+}
+
+func (policy SecurityHubConfigurationPolicy) validate() error {
+	if policy.Name == "" {
+		return errors.New("configuration policy name must be provided")
+	}
+
+	if policy.Name == SecurityHubSelfManagedPolicy {
+		return fmt.Errorf("%s is reserved and can't be used as a configuration policy name", SecurityHubSelfManagedPolicy)
+	}
+
+	if !policy.isServiceEnabled() && (len(policy.Standards) > 0 || len(policy.DisabledControls) > 0 || len(policy.ControlParameters) > 0) {
+		return fmt.Errorf("configuration policy %s disables Security Hub and can't have standards, controls or parameters", policy.Name)
+	}
+
+	for control, parameters := range policy.ControlParameters {
+		for name, value := range parameters {
+			if value.count() != 1 {
+				return fmt.Errorf("parameter %s of control %s in configuration policy %s must have exactly one value", name, control, policy.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (policy SecurityHubConfigurationPolicy) state(standardsArns []string) securityHubPolicyState {
+	state := securityHubPolicyState{
+		Description:    policy.Description,
+		ServiceEnabled: policy.isServiceEnabled(),
+	}
+
+	if state.ServiceEnabled {
+		state.Standards = sortedOrNil(standardsArns)
+		state.DisabledControls = sortedOrNil(policy.DisabledControls)
+
+		if len(policy.ControlParameters) > 0 {
+			state.Parameters = policy.ControlParameters
+		}
+	}
+
+	return state
+}
+
+func (state securityHubPolicyState) toAPI() *securityhub.Policy {
+	if !state.ServiceEnabled {
+		return &securityhub.Policy{SecurityHub: &securityhub.SecurityHubPolicy{ServiceEnabled: aws.Bool(false)}}
+	}
+
+	controls := []string{}
+	for control := range state.Parameters {
+		controls = append(controls, control)
+	}
+	sort.Strings(controls)
+
+	customParameters := []*securityhub.SecurityControlCustomParameter{}
+	for _, control := range controls {
+		parameters := make(map[string]*securityhub.ParameterConfiguration)
+		for name, value := range state.Parameters[control] {
+			parameters[name] = &securityhub.ParameterConfiguration{
+				Value:     value.toAPI(),
+				ValueType: aws.String(securityhub.ParameterValueTypeCustom),
+			}
+		}
+
+		customParameters = append(customParameters, &securityhub.SecurityControlCustomParameter{
+			Parameters:        parameters,
+			SecurityControlId: aws.String(control),
+		})
+	}
+
+	return &securityhub.Policy{
+		SecurityHub: &securityhub.SecurityHubPolicy{
+			ServiceEnabled:             aws.Bool(true),
+			EnabledStandardIdentifiers: aws.StringSlice(append([]string{}, state.Standards...)),
+			SecurityControlsConfiguration: &securityhub.SecurityControlsConfiguration{
+				DisabledSecurityControlIdentifiers: aws.StringSlice(append([]string{}, state.DisabledControls...)),
+				SecurityControlCustomParameters:    customParameters,
+			},
+		},
+	}
+}
+
+// securityHubPolicyStateFromAPI converts an existing configuration policy. Parameters left at their default value
+// are ignored.
+func securityHubPolicyStateFromAPI(policy *securityhub.GetConfigurationPolicyOutput) securityHubPolicyState {
+	state := securityHubPolicyState{Description: aws.StringValue(policy.Description)}
+
+	if policy.ConfigurationPolicy == nil || policy.ConfigurationPolicy.SecurityHub == nil {
+		return state
+	}
+
+	securityHubPolicy := policy.ConfigurationPolicy.SecurityHub
+	state.ServiceEnabled = aws.BoolValue(securityHubPolicy.ServiceEnabled)
+	state.Standards = sortedOrNil(aws.StringValueSlice(securityHubPolicy.EnabledStandardIdentifiers))
+
+	if controls := securityHubPolicy.SecurityControlsConfiguration; controls != nil {
+		state.EnabledControls = sortedOrNil(aws.StringValueSlice(controls.EnabledSecurityControlIdentifiers))
+		state.DisabledControls = sortedOrNil(aws.StringValueSlice(controls.DisabledSecurityControlIdentifiers))
+
+		for _, customParameter := range controls.SecurityControlCustomParameters {
+			for name, parameter := range customParameter.Parameters {
+				if aws.StringValue(parameter.ValueType) != securityhub.ParameterValueTypeCustom || parameter.Value == nil {
+					continue
+				}
+
+				if state.Parameters == nil {
+					state.Parameters = make(map[string]map[string]SecurityHubParameterValue)
+				}
+
+				control := aws.StringValue(customParameter.SecurityControlId)
+				if state.Parameters[control] == nil {
+					state.Parameters[control] = make(map[string]SecurityHubParameterValue)
+				}
+				state.Parameters[control][name] = securityHubParameterValueFromAPI(parameter.Value)
+			}
+		}
+	}
+
+	return state
+}
+
+// parseSecurityHubTarget parses a root ID (r-), an Organizational Unit ID (ou-) or an account ID
+func parseSecurityHubTarget(target string) (*securityhub.Target, error) {
+	switch {
+	case strings.HasPrefix(target, "r-"):
+		return &securityhub.Target{RootId: aws.String(target)}, nil
+	case strings.HasPrefix(target, "ou-"):
+		return &securityhub.Target{OrganizationalUnitId: aws.String(target)}, nil
+	case securityHubAccountIDPattern.MatchString(target):
+		return &securityhub.Target{AccountId: aws.String(target)}, nil
+	default:
+		return nil, fmt.Errorf("%s is not a valid target, expected a root ID, an Organizational Unit ID or an account ID", target)
+	}
+}
+
+func readSecurityHubConfigurationDefinitions(path string) (SecurityHubConfigurationDefinitions, error) {
+	definitions := SecurityHubConfigurationDefinitions{}
+	if err := common.ReadYAMLFile(path, &definitions); err != nil {
+		return definitions, err
+	}
+
+	names := map[string]bool{SecurityHubSelfManagedPolicy: true}
+	for _, policy := range definitions.Policies {
+		if err := policy.validate(); err != nil {
+			return definitions, err
+		}
+		if names[policy.Name] {
+			return definitions, fmt.Errorf("configuration policy %s is defined more than once", policy.Name)
+		}
+		names[policy.Name] = true
+	}
+
+	targets := make(map[string]bool)
+	for _, association := range definitions.Associations {
+		if !names[association.Policy] {
+			return definitions, fmt.Errorf("configuration policy %s is associated but not defined", association.Policy)
+		}
+
+		for _, target := range association.Targets {
+			if _, err := parseSecurityHubTarget(target); err != nil {
+				return definitions, err
+			}
+			if targets[target] {
+				return definitions, fmt.Errorf("target %s is associated more than once", target)
+			}
+			targets[target] = true
+		}
+	}
+
+	return definitions, nil
+}
+
+func (hub SecurityHub) getOrganizationConfiguration() (*securityhub.DescribeOrganizationConfigurationOutput, error) {
+	return hub.adminAccountClient.DescribeOrganizationConfiguration(&securityhub.DescribeOrganizationConfigurationInput{})
+}
+
+func (hub SecurityHub) isCentrallyConfigured() (bool, error) {
+	configuration, err := hub.getOrganizationConfiguration()
+	if err != nil {
+		return false, err
+	}
+
+	return configuration.OrganizationConfiguration != nil &&
+		aws.StringValue(configuration.OrganizationConfiguration.ConfigurationType) == securityhub.OrganizationConfigurationConfigurationTypeCentral, nil
+}
+
+// listConfigurationPolicies returns the configuration policies of the organization, keyed by name
+func (hub SecurityHub) listConfigurationPolicies() (map[string]*securityhub.ConfigurationPolicySummary, error) {
+	policies := make(map[string]*securityhub.ConfigurationPolicySummary)
+	err := hub.adminAccountClient.ListConfigurationPoliciesPages(&securityhub.ListConfigurationPoliciesInput{}, func(page *securityhub.ListConfigurationPoliciesOutput, lastPage bool) bool {
+		for _, policy := range page.ConfigurationPolicySummaries {
+			policies[aws.StringValue(policy.Name)] = policy
+		}
+		return true
+	})
+	return policies, err
+}
+
+func (hub SecurityHub) listConfigurationPolicyAssociations(filters *securityhub.AssociationFilters) ([]*securityhub.ConfigurationPolicyAssociationSummary, error) {
+	associations := []*securityhub.ConfigurationPolicyAssociationSummary{}
+	err := hub.adminAccountClient.ListConfigurationPolicyAssociationsPages(&securityhub.ListConfigurationPolicyAssociationsInput{
+		Filters: filters,
+	}, func(page *securityhub.ListConfigurationPolicyAssociationsOutput, lastPage bool) bool {
+		associations = append(associations, page.ConfigurationPolicyAssociationSummaries...)
+		return true
+	})
+	return associations, err
+}
+
+// syncConfigurationPolicies plans the changes needed to make the configuration policies match the definitions and,
+// when apply is set, makes them. It returns the ID of each policy, which is unknown for policies not created yet.
+func (hub SecurityHub) syncConfigurationPolicies(policies []SecurityHubConfigurationPolicy, apply bool) (map[string]string, error) {
+	existing, err := hub.listConfigurationPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]string{SecurityHubSelfManagedPolicy: SecurityHubSelfManagedPolicy}
+	for name, summary := range existing {
+		ids[name] = aws.StringValue(summary.Id)
+	}
+
+	for _, policy := range policies {
+		standardsArns, err := hub.resolveStandards(policy.Standards)
+		if err != nil {
+			return nil, fmt.Errorf("configuration policy %s: %v", policy.Name, err)
+		}
+		desired := policy.state(standardsArns)
+
+		summary, found := existing[policy.Name]
+		if !found {
+			logrus.Infof("    + create configuration policy %s", policy.Name)
+			if apply {
+				created, err := hub.adminAccountClient.CreateConfigurationPolicy(&securityhub.CreateConfigurationPolicyInput{
+					ConfigurationPolicy: desired.toAPI(),
+					Description:         aws.String(policy.Description),
+					Name:                aws.String(policy.Name),
+				})
+				if err != nil {
+					logrus.Error(err)
+					continue
+				}
+				ids[policy.Name] = aws.StringValue(created.Id)
+			}
+			continue
+		}
+
+		current, err := hub.adminAccountClient.GetConfigurationPolicy(&securityhub.GetConfigurationPolicyInput{Identifier: summary.Id})
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		if reflect.DeepEqual(securityHubPolicyStateFromAPI(current), desired) {
+			logrus.Infof("    = configuration policy %s is up to date", policy.Name)
+			continue
+		}
+
+		logrus.Infof("    ~ update configuration policy %s", policy.Name)
+		if apply {
+			_, err := hub.adminAccountClient.UpdateConfigurationPolicy(&securityhub.UpdateConfigurationPolicyInput{
+				ConfigurationPolicy: desired.toAPI(),
+				Description:         aws.String(policy.Description),
+				Identifier:          summary.Id,
+				Name:                aws.String(policy.Name),
+			})
+			if err != nil {
+				logrus.Error(err)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// syncConfigurationPolicyAssociations associates each target with its policy unless the policy is already applied
+// to it directly
+func (hub SecurityHub) syncConfigurationPolicyAssociations(associations []SecurityHubConfigurationAssociation, ids map[string]string, apply bool) {
+	for _, association := range associations {
+		for _, targetID := range association.Targets {
+			target, _ := parseSecurityHubTarget(targetID)
+			policyID, known := ids[association.Policy]
+
+			current, err := hub.adminAccountClient.GetConfigurationPolicyAssociation(&securityhub.GetConfigurationPolicyAssociationInput{Target: target})
+			if err != nil {
+				if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != securityhub.ErrCodeResourceNotFoundException {
+					logrus.Error(err)
+					continue
+				}
+			}
+
+			if current != nil && known && aws.StringValue(current.AssociationType) == securityhub.AssociationTypeApplied &&
+				aws.StringValue(current.ConfigurationPolicyId) == policyID {
+				logrus.Infof("    = %s is associated with %s", targetID, association.Policy)
+				continue
+			}
+
+			logrus.Infof("    + associate %s with %s", targetID, association.Policy)
+			if apply && known {
+				_, err := hub.adminAccountClient.StartConfigurationPolicyAssociation(&securityhub.StartConfigurationPolicyAssociationInput{
+					ConfigurationPolicyIdentifier: aws.String(policyID),
+					Target:                        target,
+				})
+				if err != nil {
+					logrus.Error(err)
+				}
+			}
+		}
+	}
+}
+
+// waitForConfigurationPolicyDisassociation waits until none of the targets is still associated with the configuration
+// policy. Disassociations are asynchronous, and a policy can't be deleted while they are in progress.
+func (hub SecurityHub) waitForConfigurationPolicyDisassociation(policyID string, targets []*securityhub.Target) error {
+	deadline := time.Now().Add(securityHubConfigurationTimeout)
+	for {
+		pending := 0
+		for _, target := range targets {
+			association, err := hub.adminAccountClient.GetConfigurationPolicyAssociation(&securityhub.GetConfigurationPolicyAssociationInput{Target: target})
+			if err != nil {
+				return err
+			}
+
+			status := aws.StringValue(association.AssociationStatus)
+			switch {
+			case status == securityhub.ConfigurationPolicyAssociationStatusFailed:
+				return fmt.Errorf("disassociating %s failed: %s", aws.StringValue(association.TargetId), aws.StringValue(association.AssociationStatusMessage))
+			case status == securityhub.ConfigurationPolicyAssociationStatusPending:
+				pending++
+			case aws.StringValue(association.AssociationType) == securityhub.AssociationTypeApplied && aws.StringValue(association.ConfigurationPolicyId) == policyID:
+				pending++
+			}
+		}
+
+		if pending == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %d targets to be disassociated", pending)
+		}
+
+		time.Sleep(securityHubConfigurationPollInterval)
+	}
+}
+
+// pruneConfigurationPolicies deletes the configuration policies that aren't defined, after removing their direct
+// associations. They are only reported when prune isn't set.
+func (hub SecurityHub) pruneConfigurationPolicies(policies []SecurityHubConfigurationPolicy, prune bool, apply bool) error {
+	existing, err := hub.listConfigurationPolicies()
+	if err != nil {
+		return err
+	}
+
+	defined := make(map[string]bool)
+	for _, policy := range policies {
+		defined[policy.Name] = true
+	}
+
+	for name, summary := range existing {
+		if defined[name] {
+			continue
+		}
+
+		if !prune {
+			logrus.Infof("    ! configuration policy %s is not defined, run with --prune to delete it", name)
+			continue
+		}
+
+		associations, err := hub.listConfigurationPolicyAssociations(&securityhub.AssociationFilters{
+			AssociationType:       aws.String(securityhub.AssociationTypeApplied),
+			ConfigurationPolicyId: summary.Id,
+		})
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		disassociated := make([]*securityhub.Target, 0, len(associations))
+		failed := false
+		for _, association := range associations {
+			targetID := aws.StringValue(association.TargetId)
+			logrus.Infof("    - disassociate %s from %s", targetID, name)
+			if apply {
+				target, err := parseSecurityHubTarget(targetID)
+				if err == nil {
+					_, err = hub.adminAccountClient.StartConfigurationPolicyDisassociation(&securityhub.StartConfigurationPolicyDisassociationInput{
+						ConfigurationPolicyIdentifier: summary.Id,
+						Target:                        target,
+					})
+				}
+				if err != nil {
+					logrus.Error(err)
+					failed = true
+					continue
+				}
+				disassociated = append(disassociated, target)
+			}
+		}
+
+		logrus.Infof("    - delete configuration policy %s", name)
+		if !apply {
+			continue
+		}
+
+		if failed {
+			logrus.Errorf("    configuration policy %s was not deleted because it is still associated, run again to delete it", name)
+			continue
+		}
+
+		if err := hub.waitForConfigurationPolicyDisassociation(aws.StringValue(summary.Id), disassociated); err != nil {
+			logrus.Errorf("    configuration policy %s was not deleted: %v, run again to delete it", name, err)
+			continue
+		}
+
+		if _, err := hub.adminAccountClient.DeleteConfigurationPolicy(&securityhub.DeleteConfigurationPolicyInput{Identifier: summary.Id}); err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// EnableSecurityHubCentralConfiguration switches the organization to CENTRAL configuration, so that Security Hub is
+// configured with configuration policies. The Security Hub Administrator Account must already be designated and have
+// a finding aggregator in homeRegion.
+func EnableSecurityHubCentralConfiguration(homeRegion string, administratorAccountRole string, rootRole string) error {
+	adminAccountID := GetAccountIDWithRole(GetSession(), administratorAccountRole)
+
+	hub := SecurityHub{
+		adminAccountClient:      getSecurityHubClientWithRole(homeRegion, administratorAccountRole),
+		managementAccountClient: getSecurityHubClientWithRole(homeRegion, rootRole),
+	}
+
+	if !hub.securityHubAdminAccountAlreadyEnabled(adminAccountID) {
+		return fmt.Errorf("Account %s is not the AWS Security Hub Administrator Account, run set-administrator-account first", adminAccountID)
+	}
+
+	aggregator, err := hub.getFindingAggregator()
+	if err != nil {
+		return err
+	}
+	if aggregator == nil || aws.StringValue(aggregator.FindingAggregationRegion) != homeRegion {
+		return fmt.Errorf("Central configuration requires a finding aggregator in %s, run finding-aggregator first", homeRegion)
+	}
+
+	central, err := hub.isCentrallyConfigured()
+	if err != nil {
+		return err
+	}
+
+	if central {
+		logrus.Infof("AWS Security Hub is already centrally configured from %s", homeRegion)
+	} else {
+		logrus.Infof("Switching AWS Security Hub to central configuration from %s", homeRegion)
+
+		_, err := hub.adminAccountClient.UpdateOrganizationConfiguration(&securityhub.UpdateOrganizationConfigurationInput{
+			AutoEnable:          aws.Bool(false),
+			AutoEnableStandards: aws.String(securityhub.AutoEnableStandardsNone),
+			OrganizationConfiguration: &securityhub.OrganizationConfiguration{
+				ConfigurationType: aws.String(securityhub.OrganizationConfigurationConfigurationTypeCentral),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	configuration, err := hub.getOrganizationConfiguration()
+	if err != nil {
+		return err
+	}
+
+	if configuration.OrganizationConfiguration != nil {
+		logrus.Infof("  Status: %s %s", aws.StringValue(configuration.OrganizationConfiguration.Status),
+			aws.StringValue(configuration.OrganizationConfiguration.StatusMessage))
+	}
+
+	return nil
+}
+
+// SyncSecurityHubConfiguration makes the configuration policies of the organization and their associations match the
+// definitions in a YAML file. The planned changes are always shown and are only made when apply is set. Policies that
+// aren't defined are only deleted when prune is set.
+func SyncSecurityHubConfiguration(homeRegion string, administratorAccountRole string, path string, prune bool, apply bool) error {
+	definitions, err := readSecurityHubConfigurationDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	client := getSecurityHubClientWithRole(homeRegion, administratorAccountRole)
+	hub := SecurityHub{adminAccountClient: client, currentAccountClient: client}
+
+	central, err := hub.isCentrallyConfigured()
+	if err != nil {
+		return err
+	}
+	if !central {
+		return errors.New("AWS Security Hub is not centrally configured, run configuration enable-central first")
+	}
+
+	logrus.Infof("Syncing %d AWS Security Hub configuration policies from %s", len(definitions.Policies), path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	logrus.Infof("  Processing region %s", homeRegion)
+
+	ids, err := hub.syncConfigurationPolicies(definitions.Policies, apply)
+	if err != nil {
+		return err
+	}
+
+	hub.syncConfigurationPolicyAssociations(definitions.Associations, ids, apply)
+
+	if err := hub.pruneConfigurationPolicies(definitions.Policies, prune, apply); err != nil {
+		return err
+	}
+
+	logrus.Info("AWS Security Hub configuration sync complete")
+
+	return nil
+}
+
+// ReportSecurityHubConfiguration writes every configuration policy association of the organization, and its status,
+// to stdout
+func ReportSecurityHubConfiguration(homeRegion string, administratorAccountRole string, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	hub := SecurityHub{adminAccountClient: getSecurityHubClientWithRole(homeRegion, administratorAccountRole)}
+
+	policies, err := hub.listConfigurationPolicies()
+	if err != nil {
+		return err
+	}
+
+	names := map[string]string{SecurityHubSelfManagedPolicy: SecurityHubSelfManagedPolicy}
+	for name, summary := range policies {
+		names[aws.StringValue(summary.Id)] = name
+	}
+
+	associations, err := hub.listConfigurationPolicyAssociations(nil)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(associations, func(i, j int) bool {
+		return aws.StringValue(associations[i].TargetId) < aws.StringValue(associations[j].TargetId)
+	})
+
+	headers := []string{"TARGET", "TYPE", "POLICY", "ASSOCIATION", "STATUS", "MESSAGE", "UPDATED"}
+	rows := [][]string{}
+	for _, association := range associations {
+		policy := names[aws.StringValue(association.ConfigurationPolicyId)]
+		if policy == "" {
+			policy = aws.StringValue(association.ConfigurationPolicyId)
+		}
+
+		updated := ""
+		if association.UpdatedAt != nil {
+			updated = association.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+
+		rows = append(rows, []string{
+			aws.StringValue(association.TargetId),
+			aws.StringValue(association.TargetType),
+			policy,
+			aws.StringValue(association.AssociationType),
+			aws.StringValue(association.AssociationStatus),
+			aws.StringValue(association.AssociationStatusMessage),
+			updated,
+		})
+	}
+
+	return output.Rows(os.Stdout, format, headers, rows)
+}
@@ -0,0 +1,536 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const securityHubControlNotEnabled = "NOT_ENABLED"
+
+// securityHubControlRules evaluates the control catalog for a single account in every enabled region
+type securityHubControlRules struct {
+	account                securityHubAccount
+	globalCollectionRegion string
+	isCloudTrailAccount    bool
+	catalog                SecurityHubControlCatalog
+	tags                   map[string]bool
+	regions                []string
+}
+
+// securityHubControlStatus is the current status of a control in a region
+type securityHubControlStatus struct {
+	status string
+	reason string
+}
+
+func newSecurityHubControlRules(globalCollectionRegion string, role string, isPrivileged bool, isCloudTrailAccount bool, catalogFile string, conditionTags []string) (securityHubControlRules, error) {
+	rules := securityHubControlRules{
+		globalCollectionRegion: globalCollectionRegion,
+		isCloudTrailAccount:    isCloudTrailAccount,
+		tags:                   map[string]bool{},
+	}
+
+	if role == "" && !isPrivileged {
+		return rules, errors.New("Either role must be provided or the privileged flag must be set")
+	}
+
+	catalog, err := LoadSecurityHubControlCatalog(catalogFile)
+	if err != nil {
+		return rules, err
+	}
+	rules.catalog = catalog
+
+	for _, tag := range conditionTags {
+		rules.tags[tag] = true
+	}
+
+	session := GetSession()
+	rules.account = securityHubAccount{role: role, isPrivileged: isPrivileged}
+
+	if isPrivileged {
+		rules.account.accountID = GetAccountID(session)
+	} else {
+		rules.account.accountID = GetAccountIDWithRole(session, role)
+	}
+
+	rules.regions = GetEnabledRegions("us-east-1", role, isPrivileged)
+
+	if !validateRegion(rules.regions, globalCollectionRegion) {
+		return rules, fmt.Errorf("%s is not a valid enabled region in this account", globalCollectionRegion)
+	}
+
+	return rules, nil
+}
+
+func (rules securityHubControlRules) forEachRegion(fn func(region string, hub SecurityHub, ctx securityHubControlContext)) {
+	for _, region := range rules.regions {
+		hub := SecurityHub{
+			currentAccountClient: rules.account.client(region),
+		}
+
+		isGlobalCollectionRegion := region == rules.globalCollectionRegion
+
+		if isGlobalCollectionRegion {
+			logrus.Infof("  processing global collection region %s", region)
+		} else {
+			logrus.Infof("  processing region %s", region)
+		}
+
+		fn(region, hub, securityHubControlContext{
+			isGlobalCollectionRegion: isGlobalCollectionRegion,
+			isCloudTrailAccount:      rules.isCloudTrailAccount,
+			tags:                     rules.tags,
+		})
+	}
+}
+
+// hasConsolidatedControls reports whether consolidated control findings are turned on, in which case control status
+// is managed per security control ID rather than per standard control ARN
+func (hub SecurityHub) hasConsolidatedControls() (bool, error) {
+	hubOutput, err := hub.currentAccountClient.DescribeHub(&securityhub.DescribeHubInput{})
+	if err != nil {
+		return false, err
+	}
+
+	return aws.StringValue(hubOutput.ControlFindingGenerator) == securityhub.ControlFindingGeneratorSecurityControl, nil
+}
+
+// listControlAssociations returns the association of a security control with each enabled standard that contains it
+func (hub SecurityHub) listControlAssociations(securityControlID string) ([]*securityhub.StandardsControlAssociationSummary, error) {
+	associations := []*securityhub.StandardsControlAssociationSummary{}
+
+	err := hub.currentAccountClient.ListStandardsControlAssociationsPages(&securityhub.ListStandardsControlAssociationsInput{
+		SecurityControlId: aws.String(securityControlID),
+	}, func(page *securityhub.ListStandardsControlAssociationsOutput, lastPage bool) bool {
+		associations = append(associations, page.StandardsControlAssociationSummaries...)
+		return true
+	})
+
+	return associations, err
+}
+
+func (hub SecurityHub) updateControlAssociations(updates []*securityhub.StandardsControlAssociationUpdate) error {
+	output, err := hub.currentAccountClient.BatchUpdateStandardsControlAssociations(&securityhub.BatchUpdateStandardsControlAssociationsInput{
+		StandardsControlAssociationUpdates: updates,
+	})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() == "TooManyRequestsException" {
+				logrus.Warn("    received too many requests error. Sleeping then trying again while updating control associations")
+
+				time.Sleep(2 * time.Second)
+				return hub.updateControlAssociations(updates)
+			}
+		}
+
+		return err
+	}
+
+	for _, unprocessed := range output.UnprocessedAssociationUpdates {
+		update := unprocessed.StandardsControlAssociationUpdate
+		logrus.Errorf("    could not update control %s in %s: %s %s", aws.StringValue(update.SecurityControlId),
+			aws.StringValue(update.StandardsArn), aws.StringValue(unprocessed.ErrorCode), aws.StringValue(unprocessed.ErrorReason))
+	}
+
+	return nil
+}
+
+// setSecurityControlStatus sets the status of a security control in every enabled standard that contains it
+func (hub SecurityHub) setSecurityControlStatus(securityControlID string, status string, reason string) error {
+	associations, err := hub.listControlAssociations(securityControlID)
+	if err != nil {
+		return err
+	}
+
+	updates := []*securityhub.StandardsControlAssociationUpdate{}
+	for _, association := range associations {
+		if aws.StringValue(association.AssociationStatus) == status {
+			continue
+		}
+
+		update := &securityhub.StandardsControlAssociationUpdate{
+			AssociationStatus: aws.String(status),
+			SecurityControlId: association.SecurityControlId,
+			StandardsArn:      association.StandardsArn,
+		}
+
+		if reason != "" {
+			update.UpdatedReason = aws.String(reason)
+		}
+
+		updates = append(updates, update)
+	}
+
+	if len(updates) == 0 {
+		logrus.Infof("      control %s is already %s in every enabled standard", securityControlID, status)
+		return nil
+	}
+
+	for _, update := range updates {
+		standard := securityHubStandardAlias(aws.StringValue(update.StandardsArn))
+		if standard == "" {
+			standard = aws.StringValue(update.StandardsArn)
+		}
+
+		logrus.Infof("      %s in %s", status, standard)
+	}
+
+	return hub.updateControlAssociations(updates)
+}
+
+// disableSecurityControls disables the controls by security control ID. Catalog entries of different standards that
+// share a security control ID are only disabled once, with the reason of the first entry.
+func (hub SecurityHub) disableSecurityControls(scope securityHubControlScope, controls []SecurityHubControl) {
+	seen := map[string]bool{}
+
+	for _, control := range controls {
+		securityControlID := control.securityControlID()
+		if seen[securityControlID] {
+			continue
+		}
+		seen[securityControlID] = true
+
+		reason, err := control.disabledReason(scope)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		logrus.Infof("    disabling security control %s", securityControlID)
+		err = hub.setSecurityControlStatus(securityControlID, securityhub.AssociationStatusDisabled, reason)
+
+		if err != nil {
+			logrus.Error(fmt.Errorf("could not disable security control %s: %v", securityControlID, err))
+		}
+	}
+}
+
+// enableSecurityControls enables the controls by security control ID, skipping security control IDs that are shared
+// with one of the controls to keep disabled
+func (hub SecurityHub) enableSecurityControls(controls []SecurityHubControl, keepDisabled []SecurityHubControl) {
+	seen := map[string]bool{}
+	for _, control := range keepDisabled {
+		seen[control.securityControlID()] = true
+	}
+
+	for _, control := range controls {
+		securityControlID := control.securityControlID()
+		if seen[securityControlID] {
+			continue
+		}
+		seen[securityControlID] = true
+
+		logrus.Infof("    enabling security control %s", securityControlID)
+		err := hub.setSecurityControlStatus(securityControlID, securityhub.AssociationStatusEnabled, "")
+
+		if err != nil {
+			logrus.Error(fmt.Errorf("could not enable security control %s: %v", securityControlID, err))
+		}
+	}
+}
+
+func (hub SecurityHub) enableControls(region string, accountID string, controls []SecurityHubControl, keepDisabled []SecurityHubControl) {
+	consolidated, err := hub.hasConsolidatedControls()
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	if consolidated {
+		hub.enableSecurityControls(controls, keepDisabled)
+		return
+	}
+
+	for _, control := range controls {
+		currentControl := control.arn(region, accountID)
+
+		logrus.Infof("    enabling control %s", currentControl)
+		err := hub.enableControl(currentControl)
+
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+// getControlStatuses returns the current status of each control, keyed by catalog key. Controls of standards that
+// aren't enabled are reported as NOT_ENABLED.
+func (hub SecurityHub) getControlStatuses(region string, accountID string, controls []SecurityHubControl) (map[string]securityHubControlStatus, error) {
+	statuses := map[string]securityHubControlStatus{}
+	for _, control := range controls {
+		statuses[control.key()] = securityHubControlStatus{status: securityHubControlNotEnabled}
+	}
+
+	consolidated, err := hub.hasConsolidatedControls()
+	if err != nil {
+		return statuses, err
+	}
+
+	if consolidated {
+		associations := map[string][]*securityhub.StandardsControlAssociationSummary{}
+
+		for _, control := range controls {
+			securityControlID := control.securityControlID()
+			if _, found := associations[securityControlID]; !found {
+				associations[securityControlID], err = hub.listControlAssociations(securityControlID)
+				if err != nil {
+					return statuses, err
+				}
+			}
+
+			suffix := fmt.Sprintf("/%s/v/%s", control.Standard, control.Version)
+			for _, association := range associations[securityControlID] {
+				if strings.HasSuffix(aws.StringValue(association.StandardsArn), suffix) {
+					statuses[control.key()] = securityHubControlStatus{
+						status: aws.StringValue(association.AssociationStatus),
+						reason: aws.StringValue(association.UpdatedReason),
+					}
+				}
+			}
+		}
+
+		return statuses, nil
+	}
+
+	subscriptions, err := hub.getEnabledStandards()
+	if err != nil {
+		return statuses, err
+	}
+
+	standardsControls := map[string]*securityhub.StandardsControl{}
+	for _, subscription := range subscriptions {
+		err := hub.currentAccountClient.DescribeStandardsControlsPages(&securityhub.DescribeStandardsControlsInput{
+			StandardsSubscriptionArn: subscription.StandardsSubscriptionArn,
+		}, func(page *securityhub.DescribeStandardsControlsOutput, lastPage bool) bool {
+			for _, standardsControl := range page.Controls {
+				standardsControls[aws.StringValue(standardsControl.StandardsControlArn)] = standardsControl
+			}
+			return true
+		})
+
+		if err != nil {
+			return statuses, err
+		}
+	}
+
+	for _, control := range controls {
+		if standardsControl, found := standardsControls[control.arn(region, accountID)]; found {
+			statuses[control.key()] = securityHubControlStatus{
+				status: aws.StringValue(standardsControl.ControlStatus),
+				reason: aws.StringValue(standardsControl.DisabledReason),
+			}
+		}
+	}
+
+	return statuses, nil
+}
+
+// EnableSecurityHubControls enables the controls of the catalog whose conditions don't hold, for example in the
+// global collection region after it has moved. When all is set, every control of the catalog is enabled.
+func EnableSecurityHubControls(globalCollectionRegion string, role string, isPrivileged bool, isCloudTrailAccount bool, catalogFile string, conditionTags []string, all bool) error {
+	rules, err := newSecurityHubControlRules(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount, catalogFile, conditionTags)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Enabling controls for account %s", rules.account.accountID)
+
+	rules.forEachRegion(func(region string, hub SecurityHub, ctx securityHubControlContext) {
+		controls := rules.catalog.controlsToEnable(ctx)
+		keepDisabled := rules.catalog.controlsToDisable(ctx)
+
+		if all {
+			controls = rules.catalog.Controls
+			keepDisabled = []SecurityHubControl{}
+		}
+
+		hub.enableControls(region, rules.account.accountID, controls, keepDisabled)
+	})
+
+	return nil
+}
+
+// ReportSecurityHubControlStatus writes the current status and disable reason of each control of the catalog in every
+// enabled region to stdout, next to the status computed from the catalog conditions. An error is returned when any
+// control has drifted from its desired status or the controls of a region couldn't be checked.
+func ReportSecurityHubControlStatus(globalCollectionRegion string, role string, isPrivileged bool, isCloudTrailAccount bool, catalogFile string, conditionTags []string, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	rules, err := newSecurityHubControlRules(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount, catalogFile, conditionTags)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"REGION", "STANDARD", "CONTROL", "SECURITY CONTROL", "DESIRED", "STATUS", "REASON", "DRIFT"}
+	rows := [][]string{}
+	drifted := 0
+	failed := []string{}
+
+	rules.forEachRegion(func(region string, hub SecurityHub, ctx securityHubControlContext) {
+		statuses, err := hub.getControlStatuses(region, rules.account.accountID, rules.catalog.Controls)
+		if err != nil {
+			logrus.Error(err)
+			failed = append(failed, region)
+			return
+		}
+
+		for _, control := range rules.catalog.Controls {
+			desired := securityhub.ControlStatusEnabled
+			if control.shouldDisable(ctx) {
+				desired = securityhub.ControlStatusDisabled
+			}
+
+			current := statuses[control.key()]
+			drift := ""
+			if current.status != securityHubControlNotEnabled && current.status != desired {
+				drift = "yes"
+				drifted++
+			}
+
+			rows = append(rows, []string{region, fmt.Sprintf("%s/v/%s", control.Standard, control.Version), control.Control,
+				control.securityControlID(), desired, current.status, current.reason, drift})
+		}
+	})
+
+	if err := output.Rows(os.Stdout, format, headers, rows); err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("the controls of regions %v could not be checked", failed)
+	}
+
+	if drifted > 0 {
+		return fmt.Errorf("%d controls have drifted from their desired status", drifted)
+	}
+
+	return nil
+}
+
+// SecurityHubControlList is the format of the file of controls disabled by DisableSecurityHubControls. Each control has
+// a standard, version and control, or only a securityControlId when consolidated control findings are turned on.
+type SecurityHubControlList struct {
+	Controls []SecurityHubControl `yaml:"controls"`
+}
+
+// parseSecurityHubControl parses a control given as <standard>/v/<version>/<control>, for example
+// cis-aws-foundations-benchmark/v/1.2.0/1.1, or as a security control ID such as IAM.1
+func parseSecurityHubControl(name string, reason string) (SecurityHubControl, error) {
+	i := strings.Index(name, "/v/")
+	if i < 0 {
+		return SecurityHubControl{SecurityControlID: name, Reason: reason}, nil
+	}
+
+	parts := strings.SplitN(name[i+len("/v/"):], "/", 2)
+	if i == 0 || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return SecurityHubControl{}, fmt.Errorf("%s is not a valid control, expected <standard>/v/<version>/<control> or a security control ID", name)
+	}
+
+	return SecurityHubControl{Standard: name[:i], Version: parts[0], Control: parts[1], Reason: reason}, nil
+}
+
+func validateSecurityHubControlListEntry(c SecurityHubControl) error {
+	hasStandard := c.Standard != "" || c.Version != "" || c.Control != ""
+	if hasStandard && (c.Standard == "" || c.Version == "" || c.Control == "") {
+		return fmt.Errorf("control %q must have a standard, version and control", c.key())
+	}
+
+	if !hasStandard && c.SecurityControlID == "" {
+		return errors.New("controls must have a standard, version and control, or a securityControlId")
+	}
+
+	if len(c.Conditions) > 0 {
+		return fmt.Errorf("control %s has conditions, which are only supported in the control catalog", c.key())
+	}
+
+	if c.Reason == "" {
+		return fmt.Errorf("control %s has no reason, provide one with the reason flag or in the file", c.key())
+	}
+
+	if err := c.validateReason(); err != nil {
+		return fmt.Errorf("control %s has an invalid reason: %v", c.key(), err)
+	}
+
+	return nil
+}
+
+// DisableSecurityHubControls disables the controls given by name and those listed in the file at path, if any, in
+// every targeted account and region. Controls without their own reason are disabled with reason. Reasons are templates
+// that can reference {{.Region}}, {{.AccountID}} and {{.GlobalCollectionRegion}}.
+func DisableSecurityHubControls(targets SecurityHubTargets, names []string, reason string, path string, globalCollectionRegion string) error {
+	controls := []SecurityHubControl{}
+
+	for _, name := range names {
+		control, err := parseSecurityHubControl(name, reason)
+		if err != nil {
+			return err
+		}
+		controls = append(controls, control)
+	}
+
+	if path != "" {
+		list := SecurityHubControlList{}
+		if err := common.ReadYAMLFile(path, &list); err != nil {
+			return fmt.Errorf("could not read %s: %v", path, err)
+		}
+
+		for _, control := range list.Controls {
+			if control.Reason == "" {
+				control.Reason = reason
+			}
+			controls = append(controls, control)
+		}
+	}
+
+	if len(controls) == 0 {
+		return errors.New("No controls were provided")
+	}
+
+	for _, control := range controls {
+		if err := validateSecurityHubControlListEntry(control); err != nil {
+			return err
+		}
+
+		if globalCollectionRegion == "" && control.usesGlobalCollectionRegion() {
+			return fmt.Errorf("The global collection region must be provided, the reason of control %s references {{.GlobalCollectionRegion}}", control.key())
+		}
+	}
+
+	return targets.forEach(func(accountID string, region string, hub SecurityHub) {
+		hub.disableControls(securityHubControlScope{
+			Region:                 region,
+			AccountID:              accountID,
+			GlobalCollectionRegion: globalCollectionRegion,
+		}, controls)
+	})
+}
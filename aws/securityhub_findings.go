@@ -0,0 +1,560 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudposse/turf/common/output"
+)
+
+// BatchUpdateFindings accepts at most 100 findings per request
+const securityHubFindingsBatchSize = 100
+
+var securityHubFilterExpressionPattern = regexp.MustCompile(`^([a-z-]+)\s*(>=|!=|=)\s*(.+)$`)
+
+// securityHubSeverityLabels are the severity labels of the AWS Security Finding Format, from lowest to highest
+var securityHubSeverityLabels = []string{
+	securityhub.SeverityLabelInformational,
+	securityhub.SeverityLabelLow,
+	securityhub.SeverityLabelMedium,
+	securityhub.SeverityLabelHigh,
+	securityhub.SeverityLabelCritical,
+}
+
+// SecurityHubFindingsFilterFields are the fields that can be used in findings filter expressions
+var SecurityHubFindingsFilterFields = []string{"severity", "workflow", "compliance", "account", "control", "resource-type", "record-state"}
+
+// securityHubFilterField returns the filter of the field used in a filter expression, and whether its values are
+// upper-cased enums
+func securityHubFilterField(filters *securityhub.AwsSecurityFindingFilters, field string) (*[]*securityhub.StringFilter, bool) {
+	switch field {
+	case "severity":
+		return &filters.SeverityLabel, true
+	case "workflow":
+		return &filters.WorkflowStatus, true
+	case "compliance":
+		return &filters.ComplianceStatus, true
+	case "account":
+		return &filters.AwsAccountId, false
+	case "control":
+		return &filters.ComplianceSecurityControlId, false
+	case "resource-type":
+		return &filters.ResourceType, false
+	case "record-state":
+		return &filters.RecordState, true
+	}
+	return nil, false
+}
+
+func securityHubSeverityRank(label string) int {
+	for i, severity := range securityHubSeverityLabels {
+		if severity == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseSecurityHubFindingsFilter builds findings filters from expressions of the form <field><op><values>, where op
+// is =, != or, for severity only, >=, and values are separated by |. For example `severity>=HIGH`,
+// `workflow=NEW|NOTIFIED` or `account!=111111111111`. Expressions are combined with AND, values of = with OR. Only
+// active findings are included unless record-state is given.
+func ParseSecurityHubFindingsFilter(expressions []string) (*securityhub.AwsSecurityFindingFilters, error) {
+	filters := &securityhub.AwsSecurityFindingFilters{}
+
+	for _, expression := range expressions {
+		match := securityHubFilterExpressionPattern.FindStringSubmatch(strings.TrimSpace(expression))
+		if match == nil {
+			return nil, fmt.Errorf("%q is not a valid filter, expected <field><op><values> such as severity>=HIGH", expression)
+		}
+
+		field, operator := match[1], match[2]
+		filter, isEnum := securityHubFilterField(filters, field)
+		if filter == nil {
+			return nil, fmt.Errorf("%s is not a valid filter field, must be one of %s", field, strings.Join(SecurityHubFindingsFilterFields, ", "))
+		}
+
+		values := strings.Split(match[3], "|")
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+			if isEnum {
+				values[i] = strings.ToUpper(values[i])
+			}
+		}
+
+		comparison := securityhub.StringFilterComparisonEquals
+		switch operator {
+		case "!=":
+			comparison = securityhub.StringFilterComparisonNotEquals
+		case ">=":
+			if field != "severity" || len(values) != 1 {
+				return nil, fmt.Errorf("%q is not a valid filter, >= is only supported for a single severity", expression)
+			}
+
+			rank := securityHubSeverityRank(values[0])
+			if rank < 0 {
+				return nil, fmt.Errorf("%s is not a valid severity, must be one of %s", values[0], strings.Join(securityHubSeverityLabels, ", "))
+			}
+			values = securityHubSeverityLabels[rank:]
+		}
+
+		for _, value := range values {
+			*filter = append(*filter, &securityhub.StringFilter{
+				Comparison: aws.String(comparison),
+				Value:      aws.String(value),
+			})
+		}
+	}
+
+	if len(filters.RecordState) == 0 {
+		filters.RecordState = []*securityhub.StringFilter{{
+			Comparison: aws.String(securityhub.StringFilterComparisonEquals),
+			Value:      aws.String(securityhub.RecordStateActive),
+		}}
+	}
+
+	return filters, nil
+}
+
+// SetSecurityHubFindingsSince restricts filters to findings updated within the duration
+func SetSecurityHubFindingsSince(filters *securityhub.AwsSecurityFindingFilters, since time.Duration) {
+	if since <= 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	filters.UpdatedAt = []*securityhub.DateFilter{{
+		Start: aws.String(now.Add(-since).Format(time.RFC3339)),
+		End:   aws.String(now.Format(time.RFC3339)),
+	}}
+}
+
+// getFindings returns the findings matching the filters
+func (hub SecurityHub) getFindings(filters *securityhub.AwsSecurityFindingFilters) ([]*securityhub.AwsSecurityFinding, error) {
+	findings := []*securityhub.AwsSecurityFinding{}
+	err := hub.currentAccountClient.GetFindingsPages(&securityhub.GetFindingsInput{
+		Filters:    filters,
+		MaxResults: aws.Int64(100),
+	}, func(page *securityhub.GetFindingsOutput, lastPage bool) bool {
+		findings = append(findings, page.Findings...)
+		return true
+	})
+
+	return findings, err
+}
+
+// newSecurityHubFindings returns the findings that aren't in seen and adds them to it. With a finding aggregator, the
+// aggregation region also returns the findings of the linked regions, so the same finding can be read more than once.
+func newSecurityHubFindings(seen map[string]bool, findings []*securityhub.AwsSecurityFinding) []*securityhub.AwsSecurityFinding {
+	unseen := make([]*securityhub.AwsSecurityFinding, 0, len(findings))
+	for _, finding := range findings {
+		key := aws.StringValue(finding.ProductArn) + "/" + aws.StringValue(finding.Id)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		unseen = append(unseen, finding)
+	}
+
+	return unseen
+}
+
+func securityHubFindingSeverity(finding *securityhub.AwsSecurityFinding) string {
+	if finding.Severity == nil {
+		return ""
+	}
+	return aws.StringValue(finding.Severity.Label)
+}
+
+func securityHubFindingControl(finding *securityhub.AwsSecurityFinding) string {
+	if finding.Compliance == nil {
+		return ""
+	}
+	return aws.StringValue(finding.Compliance.SecurityControlId)
+}
+
+func securityHubFindingCompliance(finding *securityhub.AwsSecurityFinding) string {
+	if finding.Compliance == nil {
+		return ""
+	}
+	return aws.StringValue(finding.Compliance.Status)
+}
+
+func securityHubFindingWorkflow(finding *securityhub.AwsSecurityFinding) string {
+	if finding.Workflow == nil {
+		return ""
+	}
+	return aws.StringValue(finding.Workflow.Status)
+}
+
+func securityHubFindingResource(finding *securityhub.AwsSecurityFinding) (string, string) {
+	if len(finding.Resources) == 0 {
+		return "", ""
+	}
+	return aws.StringValue(finding.Resources[0].Type), aws.StringValue(finding.Resources[0].Id)
+}
+
+// sortSecurityHubFindings sorts findings by severity, highest first, and then by time, most recent first
+func sortSecurityHubFindings(findings []*securityhub.AwsSecurityFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		rankI := securityHubSeverityRank(securityHubFindingSeverity(findings[i]))
+		rankJ := securityHubSeverityRank(securityHubFindingSeverity(findings[j]))
+		if rankI != rankJ {
+			return rankI > rankJ
+		}
+		return aws.StringValue(findings[i].UpdatedAt) > aws.StringValue(findings[j].UpdatedAt)
+	})
+}
+
+func writeSecurityHubFindings(findings []*securityhub.AwsSecurityFinding, format string) error {
+	if format == output.FormatJSON {
+		asff := make([]interface{}, 0, len(findings))
+		for _, finding := range findings {
+			encoded, err := encodeSecurityHubValue(finding)
+			if err != nil {
+				return err
+			}
+			asff = append(asff, encoded)
+		}
+		return output.JSON(os.Stdout, asff)
+	}
+
+	headers := []string{"SEVERITY", "REGION", "ACCOUNT", "CONTROL", "COMPLIANCE", "WORKFLOW", "RESOURCE TYPE", "RESOURCE", "UPDATED", "TITLE"}
+	rows := make([][]string, 0, len(findings))
+	for _, finding := range findings {
+		resourceType, resourceID := securityHubFindingResource(finding)
+		rows = append(rows, []string{
+			securityHubFindingSeverity(finding),
+			aws.StringValue(finding.Region),
+			aws.StringValue(finding.AwsAccountId),
+			securityHubFindingControl(finding),
+			securityHubFindingCompliance(finding),
+			securityHubFindingWorkflow(finding),
+			resourceType,
+			resourceID,
+			aws.StringValue(finding.UpdatedAt),
+			aws.StringValue(finding.Title),
+		})
+	}
+
+	return output.Rows(os.Stdout, format, headers, rows)
+}
+
+// writeSecurityHubFindingsSummary writes the number of failed findings per account and control, most failed first
+func writeSecurityHubFindingsSummary(findings []*securityhub.AwsSecurityFinding, format string) error {
+	type key struct{ account, control string }
+	failed := make(map[key]int)
+
+	for _, finding := range findings {
+		control := securityHubFindingControl(finding)
+		if control == "" || securityHubFindingCompliance(finding) != securityhub.ComplianceStatusFailed {
+			continue
+		}
+		failed[key{aws.StringValue(finding.AwsAccountId), control}]++
+	}
+
+	keys := make([]key, 0, len(failed))
+	for k := range failed {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		if failed[keys[i]] != failed[keys[j]] {
+			return failed[keys[i]] > failed[keys[j]]
+		}
+		return keys[i].control < keys[j].control
+	})
+
+	headers := []string{"ACCOUNT", "CONTROL", "FAILED"}
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{k.account, k.control, strconv.Itoa(failed[k])})
+	}
+
+	return output.Rows(os.Stdout, format, headers, rows)
+}
+
+// ExportSecurityHubFindings queries the Security Hub Administrator Account for findings matching the filter
+// expressions (see ParseSecurityHubFindingsFilter), updated within since when it is set, and writes the most severe
+// and most recent limit findings to stdout. Findings are read from the region of the finding aggregator or, when
+// allRegions is set, from every enabled region. When summary is set, the number of failed findings per account and
+// control is written instead, counting every matching finding.
+func ExportSecurityHubFindings(region string, administratorAccountRole string, allRegions bool, expressions []string, since time.Duration, limit int, summary bool, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	if summary && limit > 0 {
+		return errors.New("A limit can't be used with the summary, which counts every matching finding")
+	}
+
+	filters, err := ParseSecurityHubFindingsFilter(expressions)
+	if err != nil {
+		return err
+	}
+	SetSecurityHubFindingsSince(filters, since)
+
+	regions := GetEnabledRegions(region, administratorAccountRole, false)
+	if !allRegions {
+		aggregationRegion, err := resolveAggregationRegion(region, administratorAccountRole)
+		if err != nil {
+			return err
+		}
+		regions = []string{aggregationRegion}
+	}
+
+	logrus.Info("Querying AWS Security Hub findings")
+
+	seen := make(map[string]bool)
+	findings := []*securityhub.AwsSecurityFinding{}
+	for _, currentRegion := range regions {
+		hub := SecurityHub{currentAccountClient: getSecurityHubClientWithRole(currentRegion, administratorAccountRole)}
+
+		regionFindings, err := hub.getFindings(filters)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		regionFindings = newSecurityHubFindings(seen, regionFindings)
+		logrus.Infof("  found %d findings in region %s", len(regionFindings), currentRegion)
+		findings = append(findings, regionFindings...)
+	}
+
+	sortSecurityHubFindings(findings)
+
+	if limit > 0 && len(findings) > limit {
+		findings = findings[:limit]
+	}
+
+	if summary {
+		return writeSecurityHubFindingsSummary(findings, format)
+	}
+
+	return writeSecurityHubFindings(findings, format)
+}
+
+// SecurityHubFindingsUpdate is the change made to the selected findings. Empty fields are left unchanged.
+type SecurityHubFindingsUpdate struct {
+	WorkflowStatus    string
+	Note              string
+	UpdatedBy         string
+	UserDefinedFields map[string]string
+	SeverityLabel     string
+	VerificationState string
+}
+
+func validateSecurityHubEnum(name string, value string, values []string) error {
+	if value == "" {
+		return nil
+	}
+
+	for _, v := range values {
+		if v == value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is not a valid %s, must be one of %s", value, name, strings.Join(values, ", "))
+}
+
+func (update SecurityHubFindingsUpdate) validate() error {
+	if update.WorkflowStatus == "" && update.Note == "" && len(update.UserDefinedFields) == 0 && update.SeverityLabel == "" && update.VerificationState == "" {
+		return fmt.Errorf("at least one of workflow status, note, user-defined fields, severity label or verification state must be given")
+	}
+
+	if update.Note != "" && update.UpdatedBy == "" {
+		return fmt.Errorf("a note must have an author")
+	}
+
+	if err := validateSecurityHubEnum("workflow status", update.WorkflowStatus, securityhub.WorkflowStatus_Values()); err != nil {
+		return err
+	}
+
+	if err := validateSecurityHubEnum("severity label", update.SeverityLabel, securityhub.SeverityLabel_Values()); err != nil {
+		return err
+	}
+
+	return validateSecurityHubEnum("verification state", update.VerificationState, securityhub.VerificationState_Values())
+}
+
+// input returns the BatchUpdateFindings request that applies the update to the findings
+func (update SecurityHubFindingsUpdate) input(findings []*securityhub.AwsSecurityFinding) *securityhub.BatchUpdateFindingsInput {
+	input := &securityhub.BatchUpdateFindingsInput{}
+
+	for _, finding := range findings {
+		input.FindingIdentifiers = append(input.FindingIdentifiers, &securityhub.AwsSecurityFindingIdentifier{
+			Id:         finding.Id,
+			ProductArn: finding.ProductArn,
+		})
+	}
+
+	if update.WorkflowStatus != "" {
+		input.Workflow = &securityhub.WorkflowUpdate{Status: aws.String(update.WorkflowStatus)}
+	}
+
+	if update.Note != "" {
+		input.Note = &securityhub.NoteUpdate{Text: aws.String(update.Note), UpdatedBy: aws.String(update.UpdatedBy)}
+	}
+
+	if len(update.UserDefinedFields) > 0 {
+		input.UserDefinedFields = aws.StringMap(update.UserDefinedFields)
+	}
+
+	if update.SeverityLabel != "" {
+		input.Severity = &securityhub.SeverityUpdate{Label: aws.String(update.SeverityLabel)}
+	}
+
+	if update.VerificationState != "" {
+		input.VerificationState = aws.String(update.VerificationState)
+	}
+
+	return input
+}
+
+// updateFindings applies the update to the findings in batches and returns the number of findings that were updated.
+// Findings that couldn't be updated are logged.
+func (hub SecurityHub) updateFindings(findings []*securityhub.AwsSecurityFinding, update SecurityHubFindingsUpdate) int {
+	updated := 0
+
+	for start := 0; start < len(findings); start += securityHubFindingsBatchSize {
+		end := start + securityHubFindingsBatchSize
+		if end > len(findings) {
+			end = len(findings)
+		}
+
+		output, err := hub.batchUpdateFindings(update.input(findings[start:end]))
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		updated += len(output.ProcessedFindings)
+		for _, unprocessed := range output.UnprocessedFindings {
+			logrus.Errorf("      could not update finding %s: %s %s", aws.StringValue(unprocessed.FindingIdentifier.Id),
+				aws.StringValue(unprocessed.ErrorCode), aws.StringValue(unprocessed.ErrorMessage))
+		}
+	}
+
+	return updated
+}
+
+func (hub SecurityHub) batchUpdateFindings(input *securityhub.BatchUpdateFindingsInput) (*securityhub.BatchUpdateFindingsOutput, error) {
+	output, err := hub.currentAccountClient.BatchUpdateFindings(input)
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() == "TooManyRequestsException" {
+				logrus.Warn("      received too many requests error. Sleeping then trying again while updating findings")
+
+				time.Sleep(2 * time.Second)
+				return hub.batchUpdateFindings(input)
+			}
+		}
+	}
+
+	return output, err
+}
+
+// UpdateSecurityHubFindings sets the workflow status, note, user-defined fields, severity label or verification state
+// of the findings of the Security Hub Administrator Account that match the filter expressions (see
+// ParseSecurityHubFindingsFilter), updated within since when it is set. Findings are selected in the region of the
+// finding aggregator or, when allRegions is set, in every enabled region, where findings already matched in another
+// region are skipped. The ID of every affected finding is logged and findings are only updated when apply is set.
+func UpdateSecurityHubFindings(region string, administratorAccountRole string, allRegions bool, expressions []string, since time.Duration, update SecurityHubFindingsUpdate, apply bool) error {
+	update.WorkflowStatus = strings.ToUpper(update.WorkflowStatus)
+	update.SeverityLabel = strings.ToUpper(update.SeverityLabel)
+	update.VerificationState = strings.ToUpper(update.VerificationState)
+
+	if err := update.validate(); err != nil {
+		return err
+	}
+
+	if len(expressions) == 0 && since <= 0 {
+		return fmt.Errorf("at least one filter must be given to select the findings to update")
+	}
+
+	filters, err := ParseSecurityHubFindingsFilter(expressions)
+	if err != nil {
+		return err
+	}
+	SetSecurityHubFindingsSince(filters, since)
+
+	regions := GetEnabledRegions(region, administratorAccountRole, false)
+	if !allRegions {
+		aggregationRegion, err := resolveAggregationRegion(region, administratorAccountRole)
+		if err != nil {
+			return err
+		}
+		regions = []string{aggregationRegion}
+	}
+
+	logrus.Info("Updating AWS Security Hub findings")
+
+	seen := make(map[string]bool)
+	matched, updated := 0, 0
+	for _, currentRegion := range regions {
+		logrus.Infof("  Processing region %s", currentRegion)
+
+		hub := SecurityHub{currentAccountClient: getSecurityHubClientWithRole(currentRegion, administratorAccountRole)}
+
+		findings, err := hub.getFindings(filters)
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+		findings = newSecurityHubFindings(seen, findings)
+
+		logrus.Infof("    %d findings match", len(findings))
+		for _, finding := range findings {
+			logrus.Infof("      ~ %s (account %s)", aws.StringValue(finding.Id), aws.StringValue(finding.AwsAccountId))
+		}
+		matched += len(findings)
+
+		if apply && len(findings) > 0 {
+			regionUpdated := hub.updateFindings(findings, update)
+			logrus.Infof("    updated %d findings", regionUpdated)
+			updated += regionUpdated
+		}
+	}
+
+	if !apply {
+		logrus.Infof("%d findings would be updated", matched)
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+		return nil
+	}
+
+	logrus.Infof("Updated %d of %d findings", updated, matched)
+
+	return nil
+}
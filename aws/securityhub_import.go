@@ -0,0 +1,314 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+)
+
+// BatchImportFindings accepts at most 100 findings per request
+const securityHubImportBatchSize = 100
+
+// The longest line accepted in a JSONL file. Security Hub rejects findings larger than 240 KB.
+const securityHubMaxFindingSize = 1024 * 1024
+
+// securityHubImportedFinding is a finding read from a file, with where it was read from for error reporting
+type securityHubImportedFinding struct {
+	location string
+	finding  *securityhub.AwsSecurityFinding
+}
+
+// securityHubFindingsFromValue returns the findings of a JSON document, which can be a single finding, an array of
+// findings or a BatchImportFindings request with a Findings array
+func securityHubFindingsFromValue(v interface{}) []interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		return value
+	case map[string]interface{}:
+		if findings, ok := value["Findings"].([]interface{}); ok && len(value) == 1 {
+			return findings
+		}
+	}
+
+	return []interface{}{v}
+}
+
+func decodeSecurityHubFinding(location string, v interface{}) (securityHubImportedFinding, error) {
+	finding := &securityhub.AwsSecurityFinding{}
+	if err := decodeSecurityHubValue(v, finding); err != nil {
+		return securityHubImportedFinding{}, fmt.Errorf("%s: %v", location, err)
+	}
+
+	return securityHubImportedFinding{location: location, finding: finding}, nil
+}
+
+// readSecurityHubFindingsFile reads the findings of an ASFF file. Files ending in .jsonl or .ndjson have one finding
+// per line, other files are JSON.
+func readSecurityHubFindingsFile(path string) ([]securityHubImportedFinding, error) {
+	findings := []securityHubImportedFinding{}
+
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension != ".jsonl" && extension != ".ndjson" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var document interface{}
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+
+		for i, v := range securityHubFindingsFromValue(document) {
+			finding, err := decodeSecurityHubFinding(fmt.Sprintf("%s[%d]", path, i), v)
+			if err != nil {
+				return nil, err
+			}
+			findings = append(findings, finding)
+		}
+
+		return findings, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), securityHubMaxFindingSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		location := fmt.Sprintf("%s:%d", path, line)
+
+		var v interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &v); err != nil {
+			return nil, fmt.Errorf("%s: %v", location, err)
+		}
+
+		finding, err := decodeSecurityHubFinding(location, v)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings, scanner.Err()
+}
+
+// setSecurityHubFindingDefaults fills in the product ARN, account and region of a finding that doesn't have them. The
+// default product is the one Security Hub creates for custom findings in every account.
+func setSecurityHubFindingDefaults(finding *securityhub.AwsSecurityFinding, accountID string, region string) {
+	if finding.AwsAccountId == nil {
+		finding.AwsAccountId = aws.String(accountID)
+	}
+
+	if finding.ProductArn == nil {
+		finding.ProductArn = aws.String(fmt.Sprintf("arn:aws:securityhub:%s:%s:product/%s/default", region, accountID, accountID))
+	}
+
+	if finding.Region == nil {
+		finding.Region = aws.String(region)
+	}
+}
+
+// validateSecurityHubFinding returns the problems of a finding with the fields the AWS Security Finding Format
+// requires
+func validateSecurityHubFinding(finding *securityhub.AwsSecurityFinding) []string {
+	problems := []string{}
+
+	required := []struct {
+		name  string
+		value *string
+	}{
+		{"SchemaVersion", finding.SchemaVersion},
+		{"Id", finding.Id},
+		{"ProductArn", finding.ProductArn},
+		{"GeneratorId", finding.GeneratorId},
+		{"AwsAccountId", finding.AwsAccountId},
+		{"CreatedAt", finding.CreatedAt},
+		{"UpdatedAt", finding.UpdatedAt},
+		{"Title", finding.Title},
+		{"Description", finding.Description},
+	}
+
+	for _, field := range required {
+		if aws.StringValue(field.value) == "" {
+			problems = append(problems, fmt.Sprintf("%s is required", field.name))
+		}
+	}
+
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{{"CreatedAt", finding.CreatedAt}, {"UpdatedAt", finding.UpdatedAt}} {
+		if aws.StringValue(field.value) == "" {
+			continue
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, aws.StringValue(field.value)); err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be an ISO 8601 timestamp such as 2021-01-01T00:00:00Z", field.name))
+		}
+	}
+
+	if len(finding.Types) == 0 {
+		problems = append(problems, "Types must have at least one type")
+	}
+
+	if finding.Severity == nil || (finding.Severity.Label == nil && finding.Severity.Normalized == nil) {
+		problems = append(problems, "Severity must have a Label or Normalized")
+	}
+
+	if len(finding.Resources) == 0 {
+		problems = append(problems, "Resources must have at least one resource")
+	}
+
+	for i, resource := range finding.Resources {
+		if aws.StringValue(resource.Type) == "" || aws.StringValue(resource.Id) == "" {
+			problems = append(problems, fmt.Sprintf("Resources[%d] must have a Type and Id", i))
+		}
+	}
+
+	return problems
+}
+
+func (hub SecurityHub) batchImportFindings(findings []*securityhub.AwsSecurityFinding) (*securityhub.BatchImportFindingsOutput, error) {
+	output, err := hub.currentAccountClient.BatchImportFindings(&securityhub.BatchImportFindingsInput{Findings: findings})
+
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			if aerr.Code() == "TooManyRequestsException" {
+				logrus.Warn("    received too many requests error. Sleeping then trying again while importing findings")
+
+				time.Sleep(2 * time.Second)
+				return hub.batchImportFindings(findings)
+			}
+		}
+	}
+
+	return output, err
+}
+
+// importFindings imports the findings in batches and returns the number of findings that were imported. Findings that
+// couldn't be imported are logged.
+func (hub SecurityHub) importFindings(findings []*securityhub.AwsSecurityFinding) int {
+	imported := 0
+
+	for start := 0; start < len(findings); start += securityHubImportBatchSize {
+		end := start + securityHubImportBatchSize
+		if end > len(findings) {
+			end = len(findings)
+		}
+
+		output, err := hub.batchImportFindings(findings[start:end])
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+
+		imported += int(aws.Int64Value(output.SuccessCount))
+		for _, failed := range output.FailedFindings {
+			logrus.Errorf("    could not import finding %s: %s %s", aws.StringValue(failed.Id),
+				aws.StringValue(failed.ErrorCode), aws.StringValue(failed.ErrorMessage))
+		}
+	}
+
+	return imported
+}
+
+// ImportSecurityHubFindings imports findings in the AWS Security Finding Format into Security Hub in a single account
+// and region. Files can hold JSON, either a single finding, an array of findings or a BatchImportFindings request, or
+// one finding per line when they end in .jsonl. Findings without a product ARN, account or region get those of the
+// account's default product. Every finding is checked for the fields ASFF requires before anything is imported, and
+// findings are only imported when apply is set.
+func ImportSecurityHubFindings(region string, role string, isPrivileged bool, paths []string, apply bool) error {
+	if role == "" && !isPrivileged {
+		return errors.New("Either role must be provided or the privileged flag must be set")
+	}
+
+	imported := []securityHubImportedFinding{}
+	for _, path := range paths {
+		findings, err := readSecurityHubFindingsFile(path)
+		if err != nil {
+			return err
+		}
+		imported = append(imported, findings...)
+	}
+
+	account := securityHubAccount{role: role, isPrivileged: isPrivileged}
+	if isPrivileged {
+		account.accountID = GetAccountID(GetSession())
+	} else {
+		account.accountID = GetAccountIDWithRole(GetSession(), role)
+	}
+
+	invalid := 0
+	findings := make([]*securityhub.AwsSecurityFinding, 0, len(imported))
+	for _, current := range imported {
+		setSecurityHubFindingDefaults(current.finding, account.accountID, region)
+
+		if problems := validateSecurityHubFinding(current.finding); len(problems) > 0 {
+			logrus.Errorf("%s: %s", current.location, strings.Join(problems, ", "))
+			invalid++
+		}
+
+		findings = append(findings, current.finding)
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d findings are invalid", invalid, len(findings))
+	}
+
+	logrus.Infof("Importing %d findings into AWS Security Hub in account %s", len(findings), account.accountID)
+	logrus.Infof("  Processing region %s", region)
+
+	if !apply {
+		logrus.Infof("    %d findings would be imported", len(findings))
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+		return nil
+	}
+
+	hub := SecurityHub{currentAccountClient: account.client(region)}
+	count := hub.importFindings(findings)
+	logrus.Infof("    imported %d of %d findings", count, len(findings))
+
+	if count < len(findings) {
+		return fmt.Errorf("%d findings could not be imported", len(findings)-count)
+	}
+
+	logrus.Info("Importing AWS Security Hub findings complete")
+
+	return nil
+}
@@ -0,0 +1,317 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudposse/turf/common"
+	"github.com/cloudposse/turf/common/output"
+)
+
+// SecurityHubIntegrations is the format of the file read by SyncSecurityHubIntegrations. Products are given as
+// <company>/<product> (the end of the product ARN, e.g. aws/guardduty), a product ARN, or a product name. Products that
+// aren't listed are left alone.
+type SecurityHubIntegrations struct {
+	Enabled  []string `yaml:"enabled"`
+	Disabled []string `yaml:"disabled"`
+}
+
+// securityHubProductKey returns the <company>/<product> part of a product or product subscription ARN
+func securityHubProductKey(arn string) string {
+	for _, prefix := range []string{":product/", ":product-subscription/"} {
+		if i := strings.Index(arn, prefix); i >= 0 {
+			return arn[i+len(prefix):]
+		}
+	}
+	return arn
+}
+
+// resolveSecurityHubProduct returns the product given by key, ARN or name, or nil if it isn't available in the region
+func resolveSecurityHubProduct(products []*securityhub.Product, name string) *securityhub.Product {
+	for _, product := range products {
+		productArn := aws.StringValue(product.ProductArn)
+		if productArn == name || securityHubProductKey(productArn) == name || strings.EqualFold(aws.StringValue(product.ProductName), name) {
+			return product
+		}
+	}
+	return nil
+}
+
+func readSecurityHubIntegrations(path string) (SecurityHubIntegrations, error) {
+	integrations := SecurityHubIntegrations{}
+	if err := common.ReadYAMLFile(path, &integrations); err != nil {
+		return integrations, err
+	}
+
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, integrations.Enabled...), integrations.Disabled...) {
+		if seen[name] {
+			return integrations, fmt.Errorf("product %s is listed more than once", name)
+		}
+		seen[name] = true
+	}
+
+	return integrations, nil
+}
+
+func (hub SecurityHub) describeProducts() ([]*securityhub.Product, error) {
+	products := []*securityhub.Product{}
+	err := hub.currentAccountClient.DescribeProductsPages(&securityhub.DescribeProductsInput{}, func(page *securityhub.DescribeProductsOutput, lastPage bool) bool {
+		products = append(products, page.Products...)
+		return true
+	})
+	return products, err
+}
+
+// getEnabledProducts returns the product subscriptions of the account, keyed by <company>/<product>
+func (hub SecurityHub) getEnabledProducts() (map[string]string, error) {
+	subscriptions := make(map[string]string)
+	err := hub.currentAccountClient.ListEnabledProductsForImportPages(&securityhub.ListEnabledProductsForImportInput{}, func(page *securityhub.ListEnabledProductsForImportOutput, lastPage bool) bool {
+		for _, subscription := range page.ProductSubscriptions {
+			subscriptions[securityHubProductKey(aws.StringValue(subscription))] = aws.StringValue(subscription)
+		}
+		return true
+	})
+	return subscriptions, err
+}
+
+// securityHubProductPlan is the desired state of each configured product available in a region
+type securityHubProductPlan struct {
+	products map[string]*securityhub.Product
+	desired  map[string]bool
+	keys     []string
+}
+
+// planIntegrations resolves the configured products in the region of the hub. Products that aren't available in the
+// region are logged and skipped.
+func (hub SecurityHub) planIntegrations(integrations SecurityHubIntegrations) (securityHubProductPlan, error) {
+	plan := securityHubProductPlan{products: map[string]*securityhub.Product{}, desired: map[string]bool{}}
+
+	products, err := hub.describeProducts()
+	if err != nil {
+		return plan, err
+	}
+
+	for _, enabled := range []bool{true, false} {
+		names := integrations.Enabled
+		if !enabled {
+			names = integrations.Disabled
+		}
+
+		for _, name := range names {
+			product := resolveSecurityHubProduct(products, name)
+			if product == nil {
+				logrus.Warnf("      product %s is not available in this region", name)
+				continue
+			}
+
+			key := securityHubProductKey(aws.StringValue(product.ProductArn))
+			if _, found := plan.desired[key]; found {
+				logrus.Warnf("      product %s is listed more than once, as %s", key, name)
+				continue
+			}
+
+			plan.products[key] = product
+			plan.desired[key] = enabled
+			plan.keys = append(plan.keys, key)
+		}
+	}
+
+	sort.Strings(plan.keys)
+	return plan, nil
+}
+
+func (hub SecurityHub) syncIntegrations(integrations SecurityHubIntegrations, apply bool) error {
+	plan, err := hub.planIntegrations(integrations)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := hub.getEnabledProducts()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range plan.keys {
+		subscriptionArn, enabled := subscriptions[key]
+
+		var err error
+		switch {
+		case plan.desired[key] && !enabled:
+			logrus.Infof("      + enable import from %s", key)
+			if apply {
+				_, err = hub.currentAccountClient.EnableImportFindingsForProduct(&securityhub.EnableImportFindingsForProductInput{
+					ProductArn: plan.products[key].ProductArn,
+				})
+			}
+		case !plan.desired[key] && enabled:
+			logrus.Infof("      - disable import from %s", key)
+			if apply {
+				_, err = hub.currentAccountClient.DisableImportFindingsForProduct(&securityhub.DisableImportFindingsForProductInput{
+					ProductSubscriptionArn: aws.String(subscriptionArn),
+				})
+			}
+		default:
+			logrus.Infof("      = %s is up to date", key)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// ListSecurityHubProducts writes the products that can send findings to Security Hub in a region to stdout
+func ListSecurityHubProducts(region string, role string, isPrivileged bool, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	if role == "" && !isPrivileged {
+		return fmt.Errorf("Either role must be provided or the privileged flag must be set")
+	}
+
+	account := securityHubAccount{role: role, isPrivileged: isPrivileged}
+	hub := SecurityHub{currentAccountClient: account.client(region)}
+	products, err := hub.describeProducts()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return aws.StringValue(products[i].ProductArn) < aws.StringValue(products[j].ProductArn)
+	})
+
+	rows := make([][]string, 0, len(products))
+	for _, product := range products {
+		rows = append(rows, []string{
+			securityHubProductKey(aws.StringValue(product.ProductArn)),
+			aws.StringValue(product.CompanyName),
+			aws.StringValue(product.ProductName),
+			strings.Join(aws.StringValueSlice(product.IntegrationTypes), " "),
+		})
+	}
+
+	headers := []string{"PRODUCT", "COMPANY", "NAME", "INTEGRATION TYPES"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
+
+// SyncSecurityHubIntegrations enables and disables the import of findings from the products in a YAML file in every
+// targeted account and region. The planned changes are always shown and are only made when apply is set.
+func SyncSecurityHubIntegrations(targets SecurityHubTargets, path string, apply bool) error {
+	integrations, err := readSecurityHubIntegrations(path)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Syncing AWS Security Hub product integrations from %s", path)
+
+	if !apply {
+		logrus.Infof("Dry-run mode is active. Run again with %s flag to apply the changes", "--apply")
+	}
+
+	err = targets.forEach(func(accountID string, region string, hub SecurityHub) {
+		if err := hub.syncIntegrations(integrations, apply); err != nil {
+			logrus.Error(err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("AWS Security Hub product integrations sync complete")
+
+	return nil
+}
+
+// ReportSecurityHubIntegrations writes whether the import of findings from each product is enabled in every targeted
+// account and region to stdout. Configured products are compared with the YAML file at path, when one is given, and
+// products that are enabled but not configured are listed too.
+func ReportSecurityHubIntegrations(targets SecurityHubTargets, path string, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	integrations := SecurityHubIntegrations{}
+	if path != "" {
+		var err error
+		if integrations, err = readSecurityHubIntegrations(path); err != nil {
+			return err
+		}
+	}
+
+	rows := make([][]string, 0)
+	err := targets.forEach(func(accountID string, region string, hub SecurityHub) {
+		plan, err := hub.planIntegrations(integrations)
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		subscriptions, err := hub.getEnabledProducts()
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		keys := append([]string{}, plan.keys...)
+		for key := range subscriptions {
+			if _, configured := plan.desired[key]; !configured {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			_, enabled := subscriptions[key]
+
+			desired := ""
+			drift := ""
+			if want, configured := plan.desired[key]; configured {
+				desired = enabledOrDisabled(want)
+				if want != enabled {
+					drift = "yes"
+				}
+			}
+
+			rows = append(rows, []string{accountID, region, key, desired, enabledOrDisabled(enabled), drift})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"ACCOUNT", "REGION", "PRODUCT", "DESIRED", "STATUS", "DRIFT"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
+
+func enabledOrDisabled(enabled bool) string {
+	if enabled {
+		return "ENABLED"
+	}
+	return "DISABLED"
+}
@@ -0,0 +1,315 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/sirupsen/logrus"
+)
+
+// How often standards subscriptions are checked while waiting for them to become READY or be deleted
+const securityHubStandardsPollInterval = 5 * time.Second
+
+// securityHubStandardAliases are short names for the standards, mapped to the end of their ARNs
+var securityHubStandardAliases = map[string]string{
+	"fsbp":        "aws-foundational-security-best-practices/v/1.0.0",
+	"cis-1.2":     "cis-aws-foundations-benchmark/v/1.2.0",
+	"cis-1.4":     "cis-aws-foundations-benchmark/v/1.4.0",
+	"cis-3.0":     "cis-aws-foundations-benchmark/v/3.0.0",
+	"nist-800-53": "nist-800-53/v/5.0.0",
+	"pci-dss":     "pci-dss/v/3.2.1",
+	"tagging":     "aws-resource-tagging-standard/v/1.0.0",
+}
+
+// securityHubStandardAlias returns the short name of a standard, or an empty string if it doesn't have one
+func securityHubStandardAlias(standardsArn string) string {
+	for alias, suffix := range securityHubStandardAliases {
+		if strings.HasSuffix(standardsArn, "/"+suffix) {
+			return alias
+		}
+	}
+	return ""
+}
+
+// resolveSecurityHubStandard returns the ARN of the standard, which may be given as an alias, an ARN or the end of an
+// ARN such as `nist-800-53/v/5.0.0`
+func resolveSecurityHubStandard(standards []*securityhub.Standard, name string) (string, error) {
+	suffix := name
+	if alias, found := securityHubStandardAliases[name]; found {
+		suffix = alias
+	}
+
+	for _, standard := range standards {
+		standardsArn := aws.StringValue(standard.StandardsArn)
+		if standardsArn == name || strings.HasSuffix(standardsArn, "/"+suffix) {
+			return standardsArn, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s is not an available standard", name)
+}
+
+func (hub SecurityHub) describeStandards() ([]*securityhub.Standard, error) {
+	standards := make([]*securityhub.Standard, 0)
+	err := hub.currentAccountClient.DescribeStandardsPages(&securityhub.DescribeStandardsInput{}, func(page *securityhub.DescribeStandardsOutput, lastPage bool) bool {
+		standards = append(standards, page.Standards...)
+		return true
+	})
+	return standards, err
+}
+
+func (hub SecurityHub) resolveStandards(names []string) ([]string, error) {
+	standards, err := hub.describeStandards()
+	if err != nil {
+		return nil, err
+	}
+
+	standardsArns := make([]string, 0, len(names))
+	for _, name := range names {
+		standardsArn, err := resolveSecurityHubStandard(standards, name)
+		if err != nil {
+			return nil, err
+		}
+		standardsArns = append(standardsArns, standardsArn)
+	}
+
+	return standardsArns, nil
+}
+
+// getEnabledStandards returns the standards subscriptions of the account, keyed by standard ARN
+func (hub SecurityHub) getEnabledStandards() (map[string]*securityhub.StandardsSubscription, error) {
+	subscriptions := make(map[string]*securityhub.StandardsSubscription)
+	err := hub.currentAccountClient.GetEnabledStandardsPages(&securityhub.GetEnabledStandardsInput{}, func(page *securityhub.GetEnabledStandardsOutput, lastPage bool) bool {
+		for _, subscription := range page.StandardsSubscriptions {
+			subscriptions[aws.StringValue(subscription.StandardsArn)] = subscription
+		}
+		return true
+	})
+	return subscriptions, err
+}
+
+// waitForStandards waits until each of the standards is READY when enabled is set, or no longer subscribed otherwise
+func (hub SecurityHub) waitForStandards(standardsArns []string, enabled bool, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		subscriptions, err := hub.getEnabledStandards()
+		if err != nil {
+			return err
+		}
+
+		pending := make([]string, 0)
+		for _, standardsArn := range standardsArns {
+			subscription, found := subscriptions[standardsArn]
+			switch {
+			case enabled && !found:
+				return fmt.Errorf("standard %s is not subscribed", standardsArn)
+			case enabled && aws.StringValue(subscription.StandardsStatus) == securityhub.StandardsStatusFailed:
+				return fmt.Errorf("standard %s failed to be enabled", standardsArn)
+			case enabled && aws.StringValue(subscription.StandardsStatus) != securityhub.StandardsStatusReady:
+				pending = append(pending, standardsArn)
+			case !enabled && found:
+				pending = append(pending, standardsArn)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for standards %v", pending)
+		}
+
+		time.Sleep(securityHubStandardsPollInterval)
+	}
+}
+
+func (hub SecurityHub) enableStandards(names []string, timeout time.Duration) error {
+	standardsArns, err := hub.resolveStandards(names)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := hub.getEnabledStandards()
+	if err != nil {
+		return err
+	}
+
+	requests := make([]*securityhub.StandardsSubscriptionRequest, 0)
+	for _, standardsArn := range standardsArns {
+		if _, found := subscriptions[standardsArn]; found {
+			logrus.Infof("      standard %s is already enabled", standardsArn)
+			continue
+		}
+
+		logrus.Infof("      enabling standard %s", standardsArn)
+		requests = append(requests, &securityhub.StandardsSubscriptionRequest{StandardsArn: aws.String(standardsArn)})
+	}
+
+	if len(requests) > 0 {
+		_, err := hub.currentAccountClient.BatchEnableStandards(&securityhub.BatchEnableStandardsInput{StandardsSubscriptionRequests: requests})
+		if err != nil {
+			return err
+		}
+	}
+
+	return hub.waitForStandards(standardsArns, true, timeout)
+}
+
+func (hub SecurityHub) disableStandards(names []string, timeout time.Duration) error {
+	standardsArns, err := hub.resolveStandards(names)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := hub.getEnabledStandards()
+	if err != nil {
+		return err
+	}
+
+	subscriptionArns := make([]*string, 0)
+	for _, standardsArn := range standardsArns {
+		subscription, found := subscriptions[standardsArn]
+		if !found {
+			logrus.Infof("      standard %s is already disabled", standardsArn)
+			continue
+		}
+
+		logrus.Infof("      disabling standard %s", standardsArn)
+		subscriptionArns = append(subscriptionArns, subscription.StandardsSubscriptionArn)
+	}
+
+	if len(subscriptionArns) > 0 {
+		_, err := hub.currentAccountClient.BatchDisableStandards(&securityhub.BatchDisableStandardsInput{StandardsSubscriptionArns: subscriptionArns})
+		if err != nil {
+			return err
+		}
+	}
+
+	return hub.waitForStandards(standardsArns, false, timeout)
+}
+
+// ListSecurityHubStandards writes the standards available in the region to stdout
+func ListSecurityHubStandards(region string, role string, isPrivileged bool, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	if role == "" && !isPrivileged {
+		return fmt.Errorf("Either role must be provided or the privileged flag must be set")
+	}
+
+	account := securityHubAccount{role: role, isPrivileged: isPrivileged}
+	hub := SecurityHub{currentAccountClient: account.client(region)}
+	standards, err := hub.describeStandards()
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(standards))
+	for _, standard := range standards {
+		rows = append(rows, []string{securityHubStandardAlias(aws.StringValue(standard.StandardsArn)), aws.StringValue(standard.Name), aws.StringValue(standard.StandardsArn)})
+	}
+
+	headers := []string{"ALIAS", "NAME", "ARN"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
+
+// EnableSecurityHubStandards enables the standards in every targeted account and region, then waits until each
+// subscription is READY
+func EnableSecurityHubStandards(targets SecurityHubTargets, standards []string, timeout time.Duration) error {
+	logrus.Infof("Enabling AWS Security Hub standards %v", standards)
+
+	err := targets.forEach(func(accountID string, region string, hub SecurityHub) {
+		if err := hub.enableStandards(standards, timeout); err != nil {
+			logrus.Error(err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Enabling AWS Security Hub standards complete")
+
+	return nil
+}
+
+// DisableSecurityHubStandards disables the standards in every targeted account and region, then waits until each
+// subscription is deleted
+func DisableSecurityHubStandards(targets SecurityHubTargets, standards []string, timeout time.Duration) error {
+	logrus.Infof("Disabling AWS Security Hub standards %v", standards)
+
+	err := targets.forEach(func(accountID string, region string, hub SecurityHub) {
+		if err := hub.disableStandards(standards, timeout); err != nil {
+			logrus.Error(err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	logrus.Info("Disabling AWS Security Hub standards complete")
+
+	return nil
+}
+
+// ReportSecurityHubStandards writes the status of every standards subscription in every targeted account and region to
+// stdout
+func ReportSecurityHubStandards(targets SecurityHubTargets, format string) error {
+	if err := output.ValidateFormat(format); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0)
+	err := targets.forEach(func(accountID string, region string, hub SecurityHub) {
+		subscriptions, err := hub.getEnabledStandards()
+		if err != nil {
+			logrus.Error(err)
+			return
+		}
+
+		standardsArns := make([]string, 0, len(subscriptions))
+		for standardsArn := range subscriptions {
+			standardsArns = append(standardsArns, standardsArn)
+		}
+		sort.Strings(standardsArns)
+
+		for _, standardsArn := range standardsArns {
+			subscription := subscriptions[standardsArn]
+			reason := ""
+			if subscription.StandardsStatusReason != nil {
+				reason = aws.StringValue(subscription.StandardsStatusReason.StatusReasonCode)
+			}
+
+			rows = append(rows, []string{accountID, region, securityHubStandardAlias(standardsArn), standardsArn, aws.StringValue(subscription.StandardsStatus), reason})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"ACCOUNT", "REGION", "ALIAS", "STANDARD", "STATUS", "REASON"}
+	return output.Rows(os.Stdout, format, headers, rows)
+}
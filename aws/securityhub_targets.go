@@ -0,0 +1,140 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go/service/securityhub"
+	"github.com/sirupsen/logrus"
+)
+
+// SecurityHubTargets selects the accounts and regions a Security Hub command operates on. Either a single account is
+// targeted, using Role or the current credentials when IsPrivileged is set, or every account in the AWS Organization
+// (optionally limited to OrganizationalUnits) is targeted by assuming MemberRoleName in each of them, using RootRole to
+// list the accounts. All enabled regions are targeted unless Regions are provided.
+type SecurityHubTargets struct {
+	Role                string
+	IsPrivileged        bool
+	RootRole            string
+	MemberRoleName      string
+	OrganizationalUnits []string
+	Regions             []string
+}
+
+// securityHubAccount is a single account targeted by a Security Hub command
+type securityHubAccount struct {
+	accountID    string
+	role         string
+	isPrivileged bool
+}
+
+func (account securityHubAccount) client(region string) *securityhub.SecurityHub {
+	if account.isPrivileged {
+		return getSecurityHubClient(region)
+	}
+	return getSecurityHubClientWithRole(region, account.role)
+}
+
+func (targets SecurityHubTargets) isOrganization() bool {
+	return targets.RootRole != ""
+}
+
+func (targets SecurityHubTargets) validate() error {
+	if targets.isOrganization() {
+		if targets.MemberRoleName == "" {
+			return errors.New("The member role name must be provided when targeting the AWS Organization")
+		}
+		return nil
+	}
+
+	if len(targets.OrganizationalUnits) > 0 {
+		return errors.New("The root role must be provided when targeting Organizational Units")
+	}
+
+	if targets.Role == "" && !targets.IsPrivileged {
+		return errors.New("Either role must be provided or the privileged flag must be set")
+	}
+
+	return nil
+}
+
+// accounts returns the targeted accounts
+func (targets SecurityHubTargets) accounts() ([]securityHubAccount, error) {
+	if err := targets.validate(); err != nil {
+		return nil, err
+	}
+
+	if !targets.isOrganization() {
+		session := GetSession()
+		if targets.IsPrivileged {
+			return []securityHubAccount{{accountID: GetAccountID(session), isPrivileged: true}}, nil
+		}
+		return []securityHubAccount{{accountID: GetAccountIDWithRole(session, targets.Role), role: targets.Role}}, nil
+	}
+
+	var accountIDs []string
+	if len(targets.OrganizationalUnits) > 0 {
+		accountIDs = ListAccountIDsInOrganizationalUnits(targets.RootRole, targets.OrganizationalUnits)
+	} else {
+		accountIDs = ListMemberAccountIDs(targets.RootRole)
+	}
+
+	accounts := make([]securityHubAccount, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		accounts = append(accounts, securityHubAccount{accountID: accountID, role: GetMemberRoleArn(accountID, targets.MemberRoleName)})
+	}
+
+	return accounts, nil
+}
+
+// regions returns the targeted regions
+func (targets SecurityHubTargets) regions() ([]string, error) {
+	var enabledRegions []string
+	if targets.isOrganization() {
+		enabledRegions = GetEnabledRegions("us-east-1", targets.RootRole, false)
+	} else {
+		enabledRegions = GetEnabledRegions("us-east-1", targets.Role, targets.IsPrivileged)
+	}
+
+	return SelectRegions(enabledRegions, targets.Regions)
+}
+
+// forEach calls fn with a SecurityHub whose current account client is for the account and region, for every targeted
+// account in every targeted region
+func (targets SecurityHubTargets) forEach(fn func(accountID string, region string, hub SecurityHub)) error {
+	accounts, err := targets.accounts()
+	if err != nil {
+		return err
+	}
+
+	regions, err := targets.regions()
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		logrus.Infof("  Processing account %s", account.accountID)
+
+		for _, region := range regions {
+			logrus.Infof("    Processing region %s", region)
+			fn(account.accountID, region, SecurityHub{currentAccountClient: account.client(region)})
+		}
+	}
+
+	return nil
+}
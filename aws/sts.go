@@ -17,6 +17,8 @@ limitations under the License.
 package aws
 
 import (
+	"fmt"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
@@ -67,3 +69,8 @@ func GetAccountIDWithRole(sess *session.Session, role string) string {
 	common.AssertErrorNil(err)
 	return *ident.Account
 }
+
+// GetMemberRoleArn returns the ARN of the role with the given name in a member account of the AWS Organization
+func GetMemberRoleArn(accountID string, roleName string) string {
+	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, roleName)
+}
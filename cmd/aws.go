@@ -29,9 +29,26 @@ const roleFlag string = "role"
 const isPrivilegedFlag string = "privileged"
 const adminAccountRoleFlag string = "administrator-account-role"
 const rootRoleFlag string = "root-role"
+const outputFlag string = "output"
+const fileFlag string = "file"
+const applyFlag string = "apply"
+const pruneFlag string = "prune"
+const memberRoleNameFlag string = "member-role-name"
+const regionsFlag string = "regions"
+const accountFlag string = "account"
+const reconcileFlag string = "reconcile"
+const externalAccountsFlag string = "external-accounts"
 
 var administratorAccountRole string
 var rootRole string
+var outputFormat string
+var definitionsFile string
+var shouldApply bool
+var shouldPrune bool
+var memberRoleName string
+var regions []string
+var shouldReconcile bool
+var externalAccountsFile string
 
 var awsCmd = &cobra.Command{
 	Use:   "aws",
@@ -0,0 +1,44 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+var guardDutyConfigureDetectorsCmd = &cobra.Command{
+	Use:   "configure-detectors",
+	Short: "Apply the finding publishing frequency and tags to GuardDuty detectors in every enabled region",
+	Long: `Apply the finding publishing frequency and tags to the detectors of the GuardDuty Administrator Account, the AWS
+	Management Account and, when a member role name is provided, every member account in every enabled region. Member
+	accounts inherit the finding publishing frequency of the Administrator Account, so only their tags are updated.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ConfigureGuardDutyDetectors(region, administratorAccountRole, rootRole, memberRoleName, getGuardDutyDetectorSettings())
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyConfigureDetectorsCmd)
+
+	guardDutyConfigureDetectorsCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyConfigureDetectorsCmd.Flags().StringVarP(&rootRole, rootRoleFlag, "r", "", "The ARN of a role to assume with access to AWS Management Account")
+	guardDutyConfigureDetectorsCmd.Flags().StringVar(&memberRoleName, memberRoleNameFlag, "", "The name of a role to assume in each member account, e.g. OrganizationAccountAccessRole")
+	guardDutyConfigureDetectorsCmd.Flags().StringVar(&findingPublishingFrequency, findingPublishingFrequencyFlag, "", "The finding publishing frequency of the detectors: FIFTEEN_MINUTES, ONE_HOUR or SIX_HOURS")
+	guardDutyConfigureDetectorsCmd.Flags().StringToStringVar(&detectorTags, tagsFlag, map[string]string{}, "Tags to apply to the detectors, e.g. team=security,env=prod")
+}
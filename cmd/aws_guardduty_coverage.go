@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const minCoverageFlag string = "min-coverage"
+
+var coverageAccountIDs []string
+var minCoverage float64
+
+var guardDutyCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report GuardDuty runtime monitoring coverage in every enabled region",
+	Long: `Report the runtime monitoring coverage status of every cluster and instance, along with any issue preventing
+	coverage (such as the agent not being installed or a missing VPC endpoint), from the GuardDuty Administrator Account's
+	detector in every enabled region. When a minimum coverage percentage is provided, the command fails if the percentage
+	of healthy resources is below it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportGuardDutyCoverage(region, administratorAccountRole, coverageAccountIDs, minCoverage, outputFormat)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyCoverageCmd)
+
+	guardDutyCoverageCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyCoverageCmd.Flags().StringSliceVar(&coverageAccountIDs, accountFlag, []string{}, "Only include resources in these account IDs")
+	guardDutyCoverageCmd.Flags().Float64Var(&minCoverage, minCoverageFlag, 0, "Fail if the percentage of healthy resources is below this value")
+	guardDutyCoverageCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+}
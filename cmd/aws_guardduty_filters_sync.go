@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+var guardDutyFiltersSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync GuardDuty filters from a YAML file to every enabled region",
+	Long: `Create, update and optionally delete the filters of the GuardDuty Administrator Account's detector in every
+	enabled region so that they match the definitions in a YAML file. The planned changes are always shown, and are only
+	made when the apply flag is set. Filters that aren't in the file are only deleted when the prune flag is set.
+
+	Example file:
+
+	filters:
+	  - name: known-scanners
+	    description: Archive port probes from our vulnerability scanners
+	    action: ARCHIVE
+	    rank: 1
+	    criteria:
+	      type:
+	        equals: ["Recon:EC2/PortProbeUnprotectedPort"]
+	      service.action.networkConnectionAction.remoteIpDetails.ipAddressV4:
+	        equals: ["198.51.100.10", "198.51.100.11"]
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncGuardDutyFilters(region, administratorAccountRole, definitionsFile, shouldPrune, shouldApply)
+	},
+}
+
+func init() {
+	guardDutyFiltersCmd.AddCommand(guardDutyFiltersSyncCmd)
+
+	guardDutyFiltersSyncCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyFiltersSyncCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the filter definitions")
+	guardDutyFiltersSyncCmd.Flags().BoolVar(&shouldPrune, pruneFlag, false, "Flag to indicate if filters that aren't in the file should be deleted")
+	guardDutyFiltersSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+
+	guardDutyFiltersSyncCmd.MarkFlagRequired(fileFlag)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const severityFlag string = "severity"
+const typeFlag string = "type"
+const sinceFlag string = "since"
+const startFlag string = "start"
+const endFlag string = "end"
+const archivedFlag string = "archived"
+
+var findingsMinSeverity float64
+var findingsType string
+var findingsAccountIDs []string
+var findingsSince time.Duration
+var findingsStart string
+var findingsEnd string
+var findingsArchived bool
+
+var guardDutyFindingsCmd = &cobra.Command{
+	Use:   "findings",
+	Short: "Query GuardDuty findings across all enabled regions",
+	Long: `Query the GuardDuty Administrator Account's detector in every enabled region for findings and merge the results,
+	sorted by severity and then by time. Findings can be written as a table, JSON, CSV or JSON resembling the AWS
+	Security Finding Format (ASFF).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := getGuardDutyFindingsFilter()
+		if err != nil {
+			return err
+		}
+		return aws.ExportGuardDutyFindings(region, administratorAccountRole, filter, outputFormat)
+	},
+}
+
+// getGuardDutyFindingsFilter builds the findings filter from the flags of the findings command
+func getGuardDutyFindingsFilter() (aws.GuardDutyFindingsFilter, error) {
+	filter := aws.GuardDutyFindingsFilter{
+		MinSeverity: findingsMinSeverity,
+		Type:        findingsType,
+		AccountIDs:  findingsAccountIDs,
+		Archived:    findingsArchived,
+	}
+
+	if findingsStart != "" {
+		start, err := time.Parse(time.RFC3339, findingsStart)
+		if err != nil {
+			return filter, err
+		}
+		filter.Start = start
+	}
+
+	if findingsSince > 0 {
+		filter.Start = time.Now().Add(-findingsSince)
+	}
+
+	if findingsEnd != "" {
+		end, err := time.Parse(time.RFC3339, findingsEnd)
+		if err != nil {
+			return filter, err
+		}
+		filter.End = end
+	}
+
+	return filter, nil
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyFindingsCmd)
+
+	guardDutyFindingsCmd.PersistentFlags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyFindingsCmd.PersistentFlags().Float64Var(&findingsMinSeverity, severityFlag, 0, "Only include findings with at least this severity (LOW 1.0, MEDIUM 4.0, HIGH 7.0, CRITICAL 9.0)")
+	guardDutyFindingsCmd.PersistentFlags().StringVar(&findingsType, typeFlag, "", "Only include findings whose type matches this glob, e.g. 'Recon:EC2/*'")
+	guardDutyFindingsCmd.PersistentFlags().StringSliceVar(&findingsAccountIDs, accountFlag, []string{}, "Only include findings for these account IDs")
+	guardDutyFindingsCmd.PersistentFlags().DurationVar(&findingsSince, sinceFlag, 0, "Only include findings updated within this duration, e.g. 24h. Overrides --start")
+	guardDutyFindingsCmd.PersistentFlags().StringVar(&findingsStart, startFlag, "", "Only include findings updated at or after this RFC3339 time")
+	guardDutyFindingsCmd.PersistentFlags().StringVar(&findingsEnd, endFlag, "", "Only include findings updated at or before this RFC3339 time")
+	guardDutyFindingsCmd.PersistentFlags().BoolVar(&findingsArchived, archivedFlag, false, "Select archived findings instead of current findings")
+
+	guardDutyFindingsCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json, csv or asff")
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+var guardDutyFindingsArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive GuardDuty findings matching the filter in every enabled region",
+	Long: `Archive the current GuardDuty findings matching the filter on the GuardDuty Administrator Account's detector in
+	every enabled region. The matching findings are only counted unless the apply flag is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := getGuardDutyFindingsFilter()
+		if err != nil {
+			return err
+		}
+		return aws.SetGuardDutyFindingsArchived(region, administratorAccountRole, filter, true, shouldApply)
+	},
+}
+
+var guardDutyFindingsUnarchiveCmd = &cobra.Command{
+	Use:   "unarchive",
+	Short: "Unarchive GuardDuty findings matching the filter in every enabled region",
+	Long: `Unarchive the archived GuardDuty findings matching the filter on the GuardDuty Administrator Account's detector
+	in every enabled region. The matching findings are only counted unless the apply flag is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := getGuardDutyFindingsFilter()
+		if err != nil {
+			return err
+		}
+		return aws.SetGuardDutyFindingsArchived(region, administratorAccountRole, filter, false, shouldApply)
+	},
+}
+
+func init() {
+	guardDutyFindingsCmd.AddCommand(guardDutyFindingsArchiveCmd)
+	guardDutyFindingsCmd.AddCommand(guardDutyFindingsUnarchiveCmd)
+
+	guardDutyFindingsArchiveCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the findings should be archived")
+	guardDutyFindingsUnarchiveCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the findings should be unarchived")
+}
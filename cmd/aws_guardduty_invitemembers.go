@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+const messageFlag string = "message"
+
+var invitationMessage string
+
+var guardDutyInviteMembersCmd = &cobra.Command{
+	Use:   "invite-members",
+	Short: "Invite accounts outside of the AWS Organization as GuardDuty members",
+	Long: `Invite AWS Accounts outside of the AWS Organization, listed in a YAML file, to become members of the GuardDuty
+	Administrator Account in every enabled region. A role is then assumed in each account to accept the invitation,
+	creating a detector first if necessary. Use the status command to track the relationship status of each account.
+
+	Example file:
+
+	accounts:
+	  - accountId: "444444444444"
+	    email: aws-security@acquired.example.com
+	    role: arn:aws:iam::444444444444:role/acme-guardduty-member-admin
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.InviteGuardDutyMemberAccounts(region, administratorAccountRole, definitionsFile, invitationMessage)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyInviteMembersCmd)
+
+	guardDutyInviteMembersCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyInviteMembersCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the accounts to invite")
+	guardDutyInviteMembersCmd.Flags().StringVar(&invitationMessage, messageFlag, "", "A message to include in the invitation")
+
+	guardDutyInviteMembersCmd.MarkFlagRequired(fileFlag)
+}
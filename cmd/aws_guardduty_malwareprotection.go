@@ -0,0 +1,88 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+var guardDutyMalwareProtectionCmd = &cobra.Command{
+	Use:   "malware-protection",
+	Short: "Manage GuardDuty Malware Protection plans for S3 buckets",
+	Long: `Manage GuardDuty Malware Protection plans for S3 buckets, as declared in a YAML file. Each plan is managed from the
+	account and region of its bucket, using the credentials of the plan's accountRole when provided.
+
+	Example file:
+
+	plans:
+	  - bucket: acme-uploads
+	    region: us-east-1
+	    accountRole: arn:aws:iam::333333333333:role/acme-gbl-security-admin
+	    role: arn:aws:iam::333333333333:role/acme-guardduty-malware-protection
+	    prefixes: ["incoming/"]
+	    tagging: true
+	`,
+}
+
+var guardDutyMalwareProtectionSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Create and update GuardDuty Malware Protection plans from a YAML file",
+	Long: `Create and update the GuardDuty Malware Protection plans for the S3 buckets in a YAML file. When the prune flag is
+	set, plans for buckets that aren't in the file are deleted from the accounts and regions that appear in the file. The
+	planned changes are always shown, and are only made when the apply flag is set.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncGuardDutyMalwareProtectionPlans(definitionsFile, shouldPrune, shouldApply)
+	},
+}
+
+var guardDutyMalwareProtectionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Report the status of GuardDuty Malware Protection plans from a YAML file",
+	Long:  "Report the status of the GuardDuty Malware Protection plans for the S3 buckets in a YAML file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportGuardDutyMalwareProtectionPlans(definitionsFile, outputFormat)
+	},
+}
+
+var guardDutyMalwareProtectionDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete GuardDuty Malware Protection plans from a YAML file",
+	Long:  "Delete the GuardDuty Malware Protection plans for the S3 buckets in a YAML file. The plans are only deleted when the apply flag is set.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.DeleteGuardDutyMalwareProtectionPlans(definitionsFile, shouldApply)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyMalwareProtectionCmd)
+	guardDutyMalwareProtectionCmd.AddCommand(guardDutyMalwareProtectionSyncCmd)
+	guardDutyMalwareProtectionCmd.AddCommand(guardDutyMalwareProtectionListCmd)
+	guardDutyMalwareProtectionCmd.AddCommand(guardDutyMalwareProtectionDeleteCmd)
+
+	guardDutyMalwareProtectionCmd.PersistentFlags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the plan definitions")
+	guardDutyMalwareProtectionCmd.MarkPersistentFlagRequired(fileFlag)
+
+	guardDutyMalwareProtectionSyncCmd.Flags().BoolVar(&shouldPrune, pruneFlag, false, "Flag to indicate if plans for buckets that aren't in the file should be deleted")
+	guardDutyMalwareProtectionSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+
+	guardDutyMalwareProtectionListCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+
+	guardDutyMalwareProtectionDeleteCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the plans should be deleted")
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+const destinationArnFlag string = "destination-arn"
+const kmsKeyArnFlag string = "kms-key-arn"
+
+var destinationArn string
+var kmsKeyArn string
+
+var guardDutyPublishingDestinationCmd = &cobra.Command{
+	Use:   "publishing-destination",
+	Short: "Export GuardDuty findings to S3 in every enabled region",
+	Long: `Create or update the S3 publishing destination of the GuardDuty Administrator Account's detector in every
+	enabled region. Findings are encrypted with the provided KMS key. The destination status (PUBLISHING or
+	UNABLE_TO_PUBLISH_FIX_DESTINATION_PROPERTY) is reported for each region.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SetGuardDutyPublishingDestination(region, administratorAccountRole, destinationArn, kmsKeyArn)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyPublishingDestinationCmd)
+
+	guardDutyPublishingDestinationCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyPublishingDestinationCmd.Flags().StringVar(&destinationArn, destinationArnFlag, "", "The ARN of the S3 bucket, with an optional prefix, to publish findings to")
+	guardDutyPublishingDestinationCmd.Flags().StringVar(&kmsKeyArn, kmsKeyArnFlag, "", "The ARN of the KMS key used to encrypt published findings")
+
+	guardDutyPublishingDestinationCmd.MarkFlagRequired(destinationArnFlag)
+	guardDutyPublishingDestinationCmd.MarkFlagRequired(kmsKeyArnFlag)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const findingTypesFlag string = "finding-types"
+const timeoutFlag string = "timeout"
+const pollIntervalFlag string = "poll-interval"
+
+var findingTypes []string
+var timeout time.Duration
+var pollInterval time.Duration
+
+var guardDutySampleFindingsCmd = &cobra.Command{
+	Use:   "sample-findings",
+	Short: "Create GuardDuty sample findings to test alerting pipelines",
+	Long: `Create sample findings on the GuardDuty Administrator Account's detector in the selected regions, then poll until
+	the sample findings appear and report how long that took in each region. When no finding types are provided, a sample
+	of every finding type is created.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.CreateGuardDutySampleFindings(region, administratorAccountRole, regions, findingTypes, timeout, pollInterval, outputFormat)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutySampleFindingsCmd)
+
+	guardDutySampleFindingsCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutySampleFindingsCmd.Flags().StringSliceVar(&regions, regionsFlag, []string{}, "The regions to create sample findings in (default all enabled regions)")
+	guardDutySampleFindingsCmd.Flags().StringSliceVar(&findingTypes, findingTypesFlag, []string{}, "The finding types to create samples of (default all finding types)")
+	guardDutySampleFindingsCmd.Flags().DurationVar(&timeout, timeoutFlag, 10*time.Minute, "How long to wait for the sample findings to appear")
+	guardDutySampleFindingsCmd.Flags().DurationVar(&pollInterval, pollIntervalFlag, 10*time.Second, "How often to check whether the sample findings have appeared")
+	guardDutySampleFindingsCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+}
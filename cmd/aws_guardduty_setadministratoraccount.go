@@ -25,6 +25,16 @@ import (
 var autoEnableS3 bool
 
 const autoEnableS3Flag string = "auto-enable-s3-protection"
+const findingPublishingFrequencyFlag string = "finding-publishing-frequency"
+const tagsFlag string = "tags"
+
+var findingPublishingFrequency string
+var detectorTags map[string]string
+
+// getGuardDutyDetectorSettings builds the detector settings from the detector flags
+func getGuardDutyDetectorSettings() aws.GuardDutyDetectorSettings {
+	return aws.GuardDutyDetectorSettings{FindingPublishingFrequency: findingPublishingFrequency, Tags: detectorTags}
+}
 
 var guardDutyAddMembersCmd = &cobra.Command{
 	Use:     "set-administrator-account",
@@ -32,7 +42,7 @@ var guardDutyAddMembersCmd = &cobra.Command{
 	Short:   "Set GuardDuty administrator account and member accounts",
 	Long:    "Designate the AWS Organization's AWS GuardDuty Admininstrator Account, then enable all the AWS Organization accounts as members",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return aws.EnableGuardDutyAdministratorAccount(region, administratorAccountRole, rootRole, autoEnableS3)
+		return aws.EnableGuardDutyAdministratorAccount(region, administratorAccountRole, rootRole, autoEnableS3, getGuardDutyDetectorSettings(), shouldReconcile, externalAccountsFile)
 	},
 }
 
@@ -42,4 +52,8 @@ func init() {
 	guardDutyAddMembersCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
 	guardDutyAddMembersCmd.Flags().StringVarP(&rootRole, rootRoleFlag, "r", "", "The ARN of a role to assume with access to AWS Management Account")
 	guardDutyAddMembersCmd.Flags().BoolVarP(&autoEnableS3, autoEnableS3Flag, "", false, "Auto-enable S3 protection")
+	guardDutyAddMembersCmd.Flags().StringVar(&findingPublishingFrequency, findingPublishingFrequencyFlag, "", "The finding publishing frequency of the detectors: FIFTEEN_MINUTES, ONE_HOUR or SIX_HOURS")
+	guardDutyAddMembersCmd.Flags().StringToStringVar(&detectorTags, tagsFlag, map[string]string{}, "Tags to apply to the detectors, e.g. team=security,env=prod")
+	guardDutyAddMembersCmd.Flags().BoolVar(&shouldReconcile, reconcileFlag, false, "Flag to indicate if members that are no longer in the AWS Organization should be removed")
+	guardDutyAddMembersCmd.Flags().StringVar(&externalAccountsFile, externalAccountsFlag, "", "A YAML file listing the member accounts outside of the AWS Organization, which are never removed by --reconcile")
 }
@@ -0,0 +1,46 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+var guardDutyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report the status of GuardDuty detectors in every enabled region",
+	Long: `Report the status of the detectors of the GuardDuty Administrator Account, the AWS Management Account and the
+	member accounts in every enabled region, along with their relationship to the Administrator Account. When the finding
+	publishing frequency or tags are provided, any drift from them is reported for each detector.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportGuardDutyStatus(region, administratorAccountRole, rootRole, memberRoleName, getGuardDutyDetectorSettings(), outputFormat)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyStatusCmd)
+
+	guardDutyStatusCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyStatusCmd.Flags().StringVarP(&rootRole, rootRoleFlag, "r", "", "The ARN of a role to assume with access to AWS Management Account")
+	guardDutyStatusCmd.Flags().StringVar(&memberRoleName, memberRoleNameFlag, "", "The name of a role to assume in each member account to inspect its detector, e.g. OrganizationAccountAccessRole")
+	guardDutyStatusCmd.Flags().StringVar(&findingPublishingFrequency, findingPublishingFrequencyFlag, "", "The expected finding publishing frequency of the detectors")
+	guardDutyStatusCmd.Flags().StringToStringVar(&detectorTags, tagsFlag, map[string]string{}, "The expected tags of the detectors")
+	guardDutyStatusCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+var guardDutyThreatListsCmd = &cobra.Command{
+	Use:     "threat-lists",
+	Aliases: []string{"ipsets", "threat-intel-sets"},
+	Short:   "Manage GuardDuty trusted IP lists and threat lists",
+	Long:    "Manage GuardDuty trusted IP lists (IPSets) and threat lists (ThreatIntelSets)",
+}
+
+var guardDutyThreatListsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync GuardDuty trusted IP lists and threat lists from a YAML file to every enabled region",
+	Long: `Create, update, activate and deactivate the trusted IP lists (IPSets) and threat lists (ThreatIntelSets) of the
+	GuardDuty Administrator Account's detector in every enabled region so that they match the definitions in a YAML file.
+	The planned changes are always shown, and are only made when the apply flag is set. Supported formats are TXT, STIX,
+	OTX_CSV, ALIEN_VAULT, PROOF_POINT and FIRE_EYE.
+
+	Example file:
+
+	ipSets:
+	  - name: corporate-egress
+	    location: https://s3.amazonaws.com/acme-security/guardduty/trusted-ips.txt
+	    format: TXT
+	    activate: true
+	threatIntelSets:
+	  - name: threat-feed
+	    location: https://s3.amazonaws.com/acme-security/guardduty/threat-feed.xml
+	    format: STIX
+	    activate: true
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncGuardDutyThreatLists(region, administratorAccountRole, definitionsFile, shouldApply)
+	},
+}
+
+var guardDutyThreatListsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report the status of GuardDuty trusted IP lists and threat lists in every enabled region",
+	Long:  "Report the status of GuardDuty trusted IP lists (IPSets) and threat lists (ThreatIntelSets) in every enabled region",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportGuardDutyThreatLists(region, administratorAccountRole, outputFormat)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyThreatListsCmd)
+	guardDutyThreatListsCmd.AddCommand(guardDutyThreatListsSyncCmd)
+	guardDutyThreatListsCmd.AddCommand(guardDutyThreatListsStatusCmd)
+
+	guardDutyThreatListsCmd.PersistentFlags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+
+	guardDutyThreatListsSyncCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the list definitions")
+	guardDutyThreatListsSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+	guardDutyThreatListsSyncCmd.MarkFlagRequired(fileFlag)
+
+	guardDutyThreatListsStatusCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const groupByFlag string = "group-by"
+const daysFlag string = "days"
+
+var usageAccountIDs []string
+var usageGroupBy string
+var usageDays int
+var usageOutputFormat string
+
+var guardDutyUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report GuardDuty usage and estimated cost in every enabled region",
+	Long: `Report the usage of each account from the GuardDuty Administrator Account's detector in every enabled region,
+	broken down by feature or data source. Totals are rolled up per account (region ALL), per region (account ALL) and
+	overall.
+
+	GuardDuty only reports usage for the trailing 30 days. To estimate the cost of a different period, the usage is
+	projected over the number of days provided.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportGuardDutyUsage(region, administratorAccountRole, usageAccountIDs, usageGroupBy, usageDays, usageOutputFormat)
+	},
+}
+
+func init() {
+	guardDutyCmd.AddCommand(guardDutyUsageCmd)
+
+	guardDutyUsageCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's GuardDuty Administrator Account")
+	guardDutyUsageCmd.Flags().StringSliceVar(&usageAccountIDs, accountFlag, []string{}, "Only include usage for these account IDs")
+	guardDutyUsageCmd.Flags().StringVar(&usageGroupBy, groupByFlag, aws.GuardDutyUsageByFeature, "Break usage down by feature or data-source")
+	guardDutyUsageCmd.Flags().IntVar(&usageDays, daysFlag, 30, "The number of days to project usage over")
+	guardDutyUsageCmd.Flags().StringVarP(&usageOutputFormat, outputFlag, "o", output.FormatCSV, "The output format: table, json or csv")
+}
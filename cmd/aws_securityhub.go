@@ -18,8 +18,14 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
 )
 
+const organizationalUnitsFlag string = "organizational-units"
+
+var organizationalUnits []string
+
 var securityhubCmd = &cobra.Command{
 	Use:     "securityhub",
 	Aliases: []string{"hub", "sh"},
@@ -30,3 +36,25 @@ var securityhubCmd = &cobra.Command{
 func init() {
 	awsCmd.AddCommand(securityhubCmd)
 }
+
+// addSecurityHubTargetFlags adds the flags used to select the accounts and regions a Security Hub command operates on
+func addSecurityHubTargetFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&role, roleFlag, "", "The ARN of a role to assume to target a single account")
+	cmd.PersistentFlags().BoolVarP(&isPrivileged, isPrivilegedFlag, "", false, "Flag to indicate if the session already has rights to perform the actions in AWS")
+	cmd.PersistentFlags().StringVarP(&rootRole, rootRoleFlag, "r", "", "The ARN of a role to assume with access to AWS Management Account, to target every account in the AWS Organization")
+	cmd.PersistentFlags().StringVar(&memberRoleName, memberRoleNameFlag, "", "The name of a role to assume in each account of the AWS Organization, e.g. OrganizationAccountAccessRole")
+	cmd.PersistentFlags().StringSliceVar(&organizationalUnits, organizationalUnitsFlag, []string{}, "Only target the accounts in these Organizational Units (and their children)")
+	cmd.PersistentFlags().StringSliceVar(&regions, regionsFlag, []string{}, "The regions to target (default all enabled regions)")
+}
+
+// getSecurityHubTargets builds the targets from the flags added by addSecurityHubTargetFlags
+func getSecurityHubTargets() aws.SecurityHubTargets {
+	return aws.SecurityHubTargets{
+		Role:                role,
+		IsPrivileged:        isPrivileged,
+		RootRole:            rootRole,
+		MemberRoleName:      memberRoleName,
+		OrganizationalUnits: organizationalUnits,
+		Regions:             regions,
+	}
+}
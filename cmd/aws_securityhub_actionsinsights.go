@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+var securityHubActionsInsightsCmd = &cobra.Command{
+	Use:   "actions-insights",
+	Short: "Manage Security Hub custom actions and insights as code",
+	Long:  "Manage the custom actions and insights of the AWS Organization's AWS Security Hub Administrator Account",
+}
+
+var securityHubActionsInsightsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync Security Hub custom actions and insights from a YAML file",
+	Long: `Create, update and delete custom actions and insights to match a YAML file in each of the regions given with
+	--regions (default all enabled regions). Custom actions are matched by ID, so they can be renamed, and insights by
+	name. Custom actions and insights that aren't defined are only deleted with --prune.
+
+	Insight filters use the field names of the Security Hub API. Changes are only made with --apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncSecurityHubActionsAndInsights(region, administratorAccountRole, regions, definitionsFile, shouldPrune, shouldApply)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubActionsInsightsCmd)
+	securityHubActionsInsightsCmd.AddCommand(securityHubActionsInsightsSyncCmd)
+
+	securityHubActionsInsightsSyncCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's Security Hub Administrator Account")
+	securityHubActionsInsightsSyncCmd.Flags().StringSliceVar(&regions, regionsFlag, []string{}, "The regions to sync (default all enabled regions)")
+	securityHubActionsInsightsSyncCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the custom actions and insights")
+	securityHubActionsInsightsSyncCmd.Flags().BoolVar(&shouldPrune, pruneFlag, false, "Flag to indicate if custom actions and insights that aren't in the file should be deleted")
+	securityHubActionsInsightsSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+
+	securityHubActionsInsightsSyncCmd.MarkFlagRequired(adminAccountRoleFlag)
+	securityHubActionsInsightsSyncCmd.MarkFlagRequired(fileFlag)
+}
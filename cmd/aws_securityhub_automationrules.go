@@ -0,0 +1,65 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+var securityHubAutomationRulesCmd = &cobra.Command{
+	Use:   "automation-rules",
+	Short: "Manage Security Hub automation rules as code",
+	Long: `Manage the automation rules of the AWS Organization's AWS Security Hub Administrator Account. Rules live in the
+	region of the finding aggregator, or the region given with --region when there is none.`,
+}
+
+var securityHubAutomationRulesSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync Security Hub automation rules from a YAML file",
+	Long: `Create, update and delete automation rules to match a YAML file. The plan is shown first, with the attributes of
+	each rule that change. Rules that aren't defined are only deleted with --prune.
+
+	Criteria and actions use the field names of the Security Hub API. Changes are only made with --apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncSecurityHubAutomationRules(region, administratorAccountRole, definitionsFile, shouldPrune, shouldApply)
+	},
+}
+
+var securityHubAutomationRulesExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export Security Hub automation rules as YAML",
+	Long:  "Write the existing automation rules to stdout in the format read by sync, to bootstrap the file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ExportSecurityHubAutomationRules(region, administratorAccountRole)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubAutomationRulesCmd)
+	securityHubAutomationRulesCmd.AddCommand(securityHubAutomationRulesSyncCmd)
+	securityHubAutomationRulesCmd.AddCommand(securityHubAutomationRulesExportCmd)
+
+	securityHubAutomationRulesCmd.PersistentFlags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's Security Hub Administrator Account")
+	securityHubAutomationRulesCmd.MarkPersistentFlagRequired(adminAccountRoleFlag)
+
+	securityHubAutomationRulesSyncCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the automation rules")
+	securityHubAutomationRulesSyncCmd.Flags().BoolVar(&shouldPrune, pruneFlag, false, "Flag to indicate if automation rules that aren't in the file should be deleted")
+	securityHubAutomationRulesSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+	securityHubAutomationRulesSyncCmd.MarkFlagRequired(fileFlag)
+}
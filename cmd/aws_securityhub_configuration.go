@@ -0,0 +1,85 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+var securityHubConfigurationCmd = &cobra.Command{
+	Use:   "configuration",
+	Short: "Manage Security Hub central configuration",
+	Long: `Manage AWS Security Hub central configuration from the AWS Organization's AWS Security Hub Administrator Account
+	in the home region, the region of the finding aggregator.`,
+}
+
+var securityHubConfigurationEnableCentralCmd = &cobra.Command{
+	Use:   "enable-central",
+	Short: "Switch the AWS Organization to Security Hub central configuration",
+	Long: `Switch the AWS Organization to AWS Security Hub central configuration, where accounts are configured by the
+	configuration policies associated with them. Requires the Security Hub Administrator Account to be designated and to
+	have a finding aggregator in the home region.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.EnableSecurityHubCentralConfiguration(homeRegion, administratorAccountRole, rootRole)
+	},
+}
+
+var securityHubConfigurationSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync Security Hub configuration policies and associations from a YAML file",
+	Long: `Create or update the AWS Security Hub configuration policies defined in a YAML file and associate them with the
+	root, Organizational Units or accounts. Policies that aren't defined are only deleted with --prune.
+
+	Changes are only made with --apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncSecurityHubConfiguration(homeRegion, administratorAccountRole, definitionsFile, shouldPrune, shouldApply)
+	},
+}
+
+var securityHubConfigurationStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report Security Hub configuration policy associations",
+	Long:  "Report every AWS Security Hub configuration policy association of the AWS Organization and its status",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportSecurityHubConfiguration(homeRegion, administratorAccountRole, outputFormat)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubConfigurationCmd)
+	securityHubConfigurationCmd.AddCommand(securityHubConfigurationEnableCentralCmd)
+	securityHubConfigurationCmd.AddCommand(securityHubConfigurationSyncCmd)
+	securityHubConfigurationCmd.AddCommand(securityHubConfigurationStatusCmd)
+
+	securityHubConfigurationCmd.PersistentFlags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's Security Hub Administrator Account")
+	securityHubConfigurationCmd.PersistentFlags().StringVar(&homeRegion, homeRegionFlag, "", "The home region, where the finding aggregator is")
+	securityHubConfigurationCmd.MarkPersistentFlagRequired(adminAccountRoleFlag)
+	securityHubConfigurationCmd.MarkPersistentFlagRequired(homeRegionFlag)
+
+	securityHubConfigurationEnableCentralCmd.Flags().StringVarP(&rootRole, rootRoleFlag, "r", "", "The ARN of a role to assume with access to AWS Management Account")
+	securityHubConfigurationEnableCentralCmd.MarkFlagRequired(rootRoleFlag)
+
+	securityHubConfigurationSyncCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the configuration policies and associations")
+	securityHubConfigurationSyncCmd.Flags().BoolVar(&shouldPrune, pruneFlag, false, "Flag to indicate if configuration policies that aren't in the file should be deleted")
+	securityHubConfigurationSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+	securityHubConfigurationSyncCmd.MarkFlagRequired(fileFlag)
+
+	securityHubConfigurationStatusCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+	"github.com/spf13/cobra"
+)
+
+var securityHubControlsCmd = &cobra.Command{
+	Use:   "controls",
+	Short: "Inspect Security Hub controls",
+	Long:  "Inspect the Security Hub controls of the control catalog",
+}
+
+var securityHubControlsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report the status of Security Hub controls and detect drift",
+	Long: `
+	Lists the current status and disable reason of each control of the catalog in every enabled region, next to the
+	desired status computed from the catalog conditions. Exits non-zero when any control has drifted or a region
+	couldn't be checked.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportSecurityHubControlStatus(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount, catalogFile, conditionTags, outputFormat)
+	},
+}
+
+func init() {
+	addSecurityHubControlRuleFlags(securityHubControlsStatusCmd)
+	securityHubControlsStatusCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+
+	securityHubControlsCmd.AddCommand(securityHubControlsStatusCmd)
+	securityhubCmd.AddCommand(securityHubControlsCmd)
+}
@@ -23,9 +23,13 @@ import (
 
 const cloudTrailAccountFlag string = "cloud-trail-account"
 const globalCollectionRegionFlag string = "global-collector-region"
+const catalogFileFlag string = "catalog-file"
+const conditionTagsFlag string = "condition-tags"
 
 var isCloudTrailAccount bool
 var globalCollectionRegion string
+var catalogFile string
+var conditionTags []string
 
 var securityHubDisableGlobalControlsCmd = &cobra.Command{
 	Use:   "disable-global-controls",
@@ -34,23 +38,36 @@ var securityHubDisableGlobalControlsCmd = &cobra.Command{
 	Disables Security Hub Global Resources controls in regions that aren't collecting Global Resources and disables
 	CloudTrail related controls in accounts that are not the central CloudTrail account.
 
+	The controls come from a catalog embedded in turf. Entries can be added, replaced or removed with an override file
+	passed with --catalog-file, and catalog conditions of the form tag:<name> are enabled with --condition-tags.
+
+	In accounts with consolidated control findings turned on, controls are disabled by security control ID in every
+	enabled standard that contains them.
+
 	See the following AWS documentation for additional information:
 
 	https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-standards-fsbp-to-disable.html
 	https://docs.aws.amazon.com/securityhub/latest/userguide/securityhub-standards-cis-to-disable.html
 	`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return aws.DisableSecurityHubGlobalResourceControls(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount)
+		return aws.DisableSecurityHubGlobalResourceControls(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount, catalogFile, conditionTags)
 	},
 }
 
-func init() {
-	securityHubDisableGlobalControlsCmd.Flags().StringVarP(&globalCollectionRegion, globalCollectionRegionFlag, "g", region, "The AWS Region that contains the global resource collector")
-	securityHubDisableGlobalControlsCmd.Flags().StringVar(&role, roleFlag, "", "The ARN of a role to assume")
-	securityHubDisableGlobalControlsCmd.Flags().BoolVarP(&isPrivileged, isPrivilegedFlag, "", false, "Flag to indicate if the session already has rights to perform the actions in AWS")
-	securityHubDisableGlobalControlsCmd.Flags().BoolVar(&isCloudTrailAccount, cloudTrailAccountFlag, false, "A flag to indicate if this account is the central CloudTrail account")
+// addSecurityHubControlRuleFlags adds the flags used to evaluate the control catalog for an account
+func addSecurityHubControlRuleFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&globalCollectionRegion, globalCollectionRegionFlag, "g", region, "The AWS Region that contains the global resource collector")
+	cmd.Flags().StringVar(&role, roleFlag, "", "The ARN of a role to assume")
+	cmd.Flags().BoolVarP(&isPrivileged, isPrivilegedFlag, "", false, "Flag to indicate if the session already has rights to perform the actions in AWS")
+	cmd.Flags().BoolVar(&isCloudTrailAccount, cloudTrailAccountFlag, false, "A flag to indicate if this account is the central CloudTrail account")
+	cmd.Flags().StringVar(&catalogFile, catalogFileFlag, "", "A YAML file that adds, replaces or removes entries of the embedded control catalog")
+	cmd.Flags().StringSliceVar(&conditionTags, conditionTagsFlag, []string{}, "Enable catalog conditions of the form tag:<name>")
 
-	securityHubDisableGlobalControlsCmd.MarkFlagRequired(globalCollectionRegionFlag)
+	cmd.MarkFlagRequired(globalCollectionRegionFlag)
+}
+
+func init() {
+	addSecurityHubControlRuleFlags(securityHubDisableGlobalControlsCmd)
 
 	securityhubCmd.AddCommand(securityHubDisableGlobalControlsCmd)
 }
@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/cloudposse/turf/aws"
+	"github.com/spf13/cobra"
+)
+
+const controlsFlag string = "controls"
+const reasonFlag string = "reason"
+
+var controls []string
+var disabledReason string
+var reasonGlobalCollectionRegion string
+
+var securityHubDisableControlsCmd = &cobra.Command{
+	Use:   "disable-controls",
+	Short: "Disables a list of Security Hub controls, each with its own reason",
+	Long: `
+	Disables the controls given with --controls, and those listed in the file given with --file, in every targeted
+	account and region. Controls are given as <standard>/v/<version>/<control>, for example
+	cis-aws-foundations-benchmark/v/1.2.0/1.1, or as a security control ID such as IAM.1 when consolidated control
+	findings are turned on.
+
+	Controls without their own reason in the file are disabled with --reason. Reasons are templates that can reference
+	{{.Region}}, {{.AccountID}} and {{.GlobalCollectionRegion}}.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.DisableSecurityHubControls(getSecurityHubTargets(), controls, disabledReason, definitionsFile, reasonGlobalCollectionRegion)
+	},
+}
+
+func init() {
+	addSecurityHubTargetFlags(securityHubDisableControlsCmd)
+	securityHubDisableControlsCmd.Flags().StringSliceVar(&controls, controlsFlag, []string{}, "The controls to disable")
+	securityHubDisableControlsCmd.Flags().StringVar(&disabledReason, reasonFlag, "", "The reason to disable controls with, a template that can reference {{.Region}}, {{.AccountID}} and {{.GlobalCollectionRegion}}")
+	securityHubDisableControlsCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "A YAML file with the controls to disable and their reasons")
+	securityHubDisableControlsCmd.Flags().StringVarP(&reasonGlobalCollectionRegion, globalCollectionRegionFlag, "g", "", "The AWS Region that contains the global resource collector, required when a reason references {{.GlobalCollectionRegion}}")
+
+	securityhubCmd.AddCommand(securityHubDisableControlsCmd)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/cloudposse/turf/aws"
+	"github.com/spf13/cobra"
+)
+
+const allFlag string = "all"
+
+var enableAllControls bool
+
+var securityHubEnableControlsCmd = &cobra.Command{
+	Use:   "enable-controls",
+	Short: "Enables the Security Hub controls that disable-global-controls would leave enabled",
+	Long: `
+	Enables the controls of the catalog whose conditions don't hold in each region. This is the counterpart of
+	disable-global-controls, for example to re-enable Global Resources controls in a new global collection region.
+
+	With --all, every control of the catalog is enabled regardless of its conditions.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.EnableSecurityHubControls(globalCollectionRegion, role, isPrivileged, isCloudTrailAccount, catalogFile, conditionTags, enableAllControls)
+	},
+}
+
+func init() {
+	addSecurityHubControlRuleFlags(securityHubEnableControlsCmd)
+	securityHubEnableControlsCmd.Flags().BoolVar(&enableAllControls, allFlag, false, "Enable every control of the catalog")
+
+	securityhubCmd.AddCommand(securityHubEnableControlsCmd)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+const homeRegionFlag string = "home-region"
+const linkingModeFlag string = "linking-mode"
+
+var homeRegion string
+var linkingMode string
+
+var securityHubFindingAggregatorCmd = &cobra.Command{
+	Use:   "finding-aggregator",
+	Short: "Aggregate Security Hub findings from other regions into a home region",
+	Long: `Create or update the finding aggregator of the AWS Organization's AWS Security Hub Administrator Account in the
+	home region, then report the regions whose findings are aggregated.
+
+	The linking mode is ALL_REGIONS, ALL_REGIONS_EXCEPT_SPECIFIED or SPECIFIED_REGIONS. The latter two take the regions
+	given with --regions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SetSecurityHubFindingAggregator(homeRegion, administratorAccountRole, linkingMode, regions)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubFindingAggregatorCmd)
+
+	securityHubFindingAggregatorCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's Security Hub Administrator Account")
+	securityHubFindingAggregatorCmd.Flags().StringVar(&homeRegion, homeRegionFlag, "", "The region findings are aggregated into")
+	securityHubFindingAggregatorCmd.Flags().StringVar(&linkingMode, linkingModeFlag, aws.SecurityHubLinkAllRegions, "How regions are linked: ALL_REGIONS, ALL_REGIONS_EXCEPT_SPECIFIED or SPECIFIED_REGIONS")
+	securityHubFindingAggregatorCmd.Flags().StringSliceVar(&regions, regionsFlag, []string{}, "The regions to link or exclude, depending on the linking mode")
+
+	securityHubFindingAggregatorCmd.MarkFlagRequired(adminAccountRoleFlag)
+	securityHubFindingAggregatorCmd.MarkFlagRequired(homeRegionFlag)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const filterFlag string = "filter"
+const allRegionsFlag string = "all-regions"
+const limitFlag string = "limit"
+const summaryFlag string = "summary"
+
+var securityHubFindingsFilters []string
+var securityHubFindingsSince time.Duration
+var securityHubFindingsAllRegions bool
+var securityHubFindingsLimit int
+var securityHubFindingsSummary bool
+
+var securityHubFindingsCmd = &cobra.Command{
+	Use:   "findings",
+	Short: "Query Security Hub findings",
+	Long: `Query the Security Hub Administrator Account for findings, sorted by severity and then by time. Findings are read
+	from the region of the finding aggregator, or the region given with --region when there is none. With
+	--all-regions, every enabled region is queried and the results are merged.
+
+	Findings are selected with repeated --filter expressions of the form <field><op><values>, which are combined with
+	AND. The fields are severity, workflow, compliance, account, control, resource-type and record-state. The operators
+	are = and !=, and >= for severity. Multiple values are separated by |. Only active findings are included unless
+	record-state is filtered on. For example:
+
+	  --filter 'severity>=HIGH' --filter 'workflow=NEW|NOTIFIED' --filter 'compliance=FAILED'
+
+	Findings can be written as a table, JSON (ASFF) or CSV. With --summary, the number of failed findings per account
+	and control is written instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ExportSecurityHubFindings(region, administratorAccountRole, securityHubFindingsAllRegions, securityHubFindingsFilters,
+			securityHubFindingsSince, securityHubFindingsLimit, securityHubFindingsSummary, outputFormat)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubFindingsCmd)
+
+	securityHubFindingsCmd.PersistentFlags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's Security Hub Administrator Account")
+	securityHubFindingsCmd.PersistentFlags().StringArrayVar(&securityHubFindingsFilters, filterFlag, []string{}, "A filter expression such as 'severity>=HIGH', can be repeated")
+	securityHubFindingsCmd.PersistentFlags().DurationVar(&securityHubFindingsSince, sinceFlag, 0, "Only include findings updated within this duration, e.g. 720h")
+	securityHubFindingsCmd.PersistentFlags().BoolVar(&securityHubFindingsAllRegions, allRegionsFlag, false, "Query every enabled region instead of the region of the finding aggregator")
+	securityHubFindingsCmd.MarkPersistentFlagRequired(adminAccountRoleFlag)
+
+	securityHubFindingsCmd.Flags().IntVar(&securityHubFindingsLimit, limitFlag, 0, "The maximum number of findings to include, most severe first, 0 for no limit. Can't be used with --summary")
+	securityHubFindingsCmd.Flags().BoolVar(&securityHubFindingsSummary, summaryFlag, false, "Write the number of failed findings per account and control instead of the findings")
+	securityHubFindingsCmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+const workflowStatusFlag string = "workflow-status"
+const noteFlag string = "note"
+const updatedByFlag string = "updated-by"
+const userDefinedFieldsFlag string = "user-defined-fields"
+const severityLabelFlag string = "severity-label"
+const verificationStateFlag string = "verification-state"
+
+var securityHubFindingsUpdate aws.SecurityHubFindingsUpdate
+
+var securityHubFindingsUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update the workflow status, note and other fields of Security Hub findings",
+	Long: `Select findings with the same filters as the findings command and update them with BatchUpdateFindings, for example
+	to suppress the findings of an accepted risk with a note. The workflow status, note, user-defined fields, severity
+	label and verification state can be set; fields that aren't given are left unchanged.
+
+	The ID of every matching finding is logged. Findings are only updated with --apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.UpdateSecurityHubFindings(region, administratorAccountRole, securityHubFindingsAllRegions, securityHubFindingsFilters,
+			securityHubFindingsSince, securityHubFindingsUpdate, shouldApply)
+	},
+}
+
+func init() {
+	securityHubFindingsCmd.AddCommand(securityHubFindingsUpdateCmd)
+
+	securityHubFindingsUpdateCmd.Flags().StringVar(&securityHubFindingsUpdate.WorkflowStatus, workflowStatusFlag, "", "The workflow status to set: NEW, NOTIFIED, RESOLVED or SUPPRESSED")
+	securityHubFindingsUpdateCmd.Flags().StringVar(&securityHubFindingsUpdate.Note, noteFlag, "", "The note to set")
+	securityHubFindingsUpdateCmd.Flags().StringVar(&securityHubFindingsUpdate.UpdatedBy, updatedByFlag, "turf", "The author of the note")
+	securityHubFindingsUpdateCmd.Flags().StringToStringVar(&securityHubFindingsUpdate.UserDefinedFields, userDefinedFieldsFlag, map[string]string{}, "The user-defined fields to set, e.g. ticket=SEC-123")
+	securityHubFindingsUpdateCmd.Flags().StringVar(&securityHubFindingsUpdate.SeverityLabel, severityLabelFlag, "", "The severity label to set: INFORMATIONAL, LOW, MEDIUM, HIGH or CRITICAL")
+	securityHubFindingsUpdateCmd.Flags().StringVar(&securityHubFindingsUpdate.VerificationState, verificationStateFlag, "", "The verification state to set: UNKNOWN, TRUE_POSITIVE, FALSE_POSITIVE or BENIGN_POSITIVE")
+	securityHubFindingsUpdateCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+}
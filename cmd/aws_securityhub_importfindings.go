@@ -0,0 +1,50 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+)
+
+var importFindingsFiles []string
+
+var securityHubImportFindingsCmd = &cobra.Command{
+	Use:   "import-findings",
+	Short: "Import custom findings into Security Hub from ASFF files",
+	Long: `Import findings in the AWS Security Finding Format (ASFF) into Security Hub in the account and region, for example
+	the results of Terraform scanners or internal checks. Files can hold JSON, either a single finding, an array of
+	findings or a BatchImportFindings request, or one finding per line when they end in .jsonl.
+
+	Findings without a ProductArn, AwsAccountId or Region get those of the account's default product. Every finding is
+	checked for the fields ASFF requires before anything is imported, and findings that Security Hub rejects are
+	reported one by one. Findings are only imported with --apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ImportSecurityHubFindings(region, role, isPrivileged, importFindingsFiles, shouldApply)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubImportFindingsCmd)
+
+	securityHubImportFindingsCmd.Flags().StringVar(&role, roleFlag, "", "The ARN of a role to assume")
+	securityHubImportFindingsCmd.Flags().BoolVarP(&isPrivileged, isPrivilegedFlag, "", false, "Flag to indicate if the session already has rights to perform the actions in AWS")
+	securityHubImportFindingsCmd.Flags().StringSliceVarP(&importFindingsFiles, fileFlag, "f", []string{}, "The ASFF JSON or JSONL files containing the findings, can be repeated")
+	securityHubImportFindingsCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+	securityHubImportFindingsCmd.MarkFlagRequired(fileFlag)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+var securityHubIntegrationsCmd = &cobra.Command{
+	Use:   "integrations",
+	Short: "Manage Security Hub product integrations",
+	Long: `Manage which products send findings to Security Hub in a single account, or in every account of the AWS
+	Organization (optionally limited to Organizational Units), in every enabled region.
+
+	Products are given as <company>/<product> (e.g. aws/guardduty, as shown by list), a product ARN, or a product name.`,
+}
+
+var securityHubIntegrationsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the products that can send findings to Security Hub in a region",
+	Long:  "List the products that can send findings to Security Hub in a region",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ListSecurityHubProducts(region, role, isPrivileged, outputFormat)
+	},
+}
+
+var securityHubIntegrationsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Enable and disable Security Hub product integrations from a YAML file",
+	Long: `Enable the import of findings from the products listed under enabled, and disable it for those listed under
+	disabled, in every targeted account and region. Products that aren't listed are left alone.
+
+	Changes are only made with --apply.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.SyncSecurityHubIntegrations(getSecurityHubTargets(), definitionsFile, shouldApply)
+	},
+}
+
+var securityHubIntegrationsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report Security Hub product integrations",
+	Long: `Report the products that send findings to Security Hub in every targeted account and region. With --file, the
+	configured products are compared with the file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportSecurityHubIntegrations(getSecurityHubTargets(), definitionsFile, outputFormat)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubIntegrationsCmd)
+	securityHubIntegrationsCmd.AddCommand(securityHubIntegrationsListCmd)
+	securityHubIntegrationsCmd.AddCommand(securityHubIntegrationsSyncCmd)
+	securityHubIntegrationsCmd.AddCommand(securityHubIntegrationsStatusCmd)
+
+	addSecurityHubTargetFlags(securityHubIntegrationsCmd)
+
+	securityHubIntegrationsSyncCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the products to enable and disable")
+	securityHubIntegrationsSyncCmd.Flags().BoolVar(&shouldApply, applyFlag, false, "Flag to indicate if the changes should be made")
+	securityHubIntegrationsSyncCmd.MarkFlagRequired(fileFlag)
+
+	securityHubIntegrationsStatusCmd.Flags().StringVarP(&definitionsFile, fileFlag, "f", "", "The YAML file containing the products to enable and disable")
+
+	for _, cmd := range []*cobra.Command{securityHubIntegrationsListCmd, securityHubIntegrationsStatusCmd} {
+		cmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+	}
+}
@@ -28,7 +28,7 @@ var securityHubAddMembersCmd = &cobra.Command{
 	Short:   "Set Security Hub administrator account and member accounts",
 	Long:    "Designate the AWS Organization's AWS Security Hub Admininstrator Account, then enabled all the AWS Organization accounts as members",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return aws.EnableSecurityHubAdministratorAccount(region, administratorAccountRole, rootRole)
+		return aws.EnableSecurityHubAdministratorAccount(region, administratorAccountRole, rootRole, shouldReconcile, externalAccountsFile)
 	},
 }
 
@@ -37,4 +37,6 @@ func init() {
 
 	securityHubAddMembersCmd.Flags().StringVarP(&administratorAccountRole, adminAccountRoleFlag, "a", "", "The ARN of a role to assume with access to the organization's Security Hub Administrator Account")
 	securityHubAddMembersCmd.Flags().StringVarP(&rootRole, rootRoleFlag, "r", "", "The ARN of a role to assume with access to AWS Management Account")
+	securityHubAddMembersCmd.Flags().BoolVar(&shouldReconcile, reconcileFlag, false, "Flag to indicate if members that are no longer in the AWS Organization should be removed")
+	securityHubAddMembersCmd.Flags().StringVar(&externalAccountsFile, externalAccountsFlag, "", "A YAML file listing the member accounts outside of the AWS Organization, which are never removed by --reconcile")
 }
@@ -0,0 +1,97 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cloudposse/turf/aws"
+	"github.com/cloudposse/turf/common/output"
+)
+
+const standardsFlag string = "standards"
+
+var standards []string
+var standardsTimeout time.Duration
+
+var securityHubStandardsCmd = &cobra.Command{
+	Use:   "standards",
+	Short: "Manage Security Hub standards subscriptions",
+	Long: `Manage Security Hub standards subscriptions in a single account, or in every account of the AWS Organization
+	(optionally limited to Organizational Units), in every enabled region.
+
+	Standards can be given as an alias (fsbp, cis-1.2, cis-1.4, cis-3.0, nist-800-53, pci-dss, tagging), an ARN, or the
+	end of an ARN such as nist-800-53/v/5.0.0.`,
+}
+
+var securityHubStandardsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the Security Hub standards available in a region",
+	Long:  "List the Security Hub standards available in a region",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ListSecurityHubStandards(region, role, isPrivileged, outputFormat)
+	},
+}
+
+var securityHubStandardsEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable Security Hub standards and wait until they are READY",
+	Long:  "Enable Security Hub standards in every targeted account and region, then wait until each subscription is READY",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.EnableSecurityHubStandards(getSecurityHubTargets(), standards, standardsTimeout)
+	},
+}
+
+var securityHubStandardsDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable Security Hub standards and wait until they are deleted",
+	Long:  "Disable Security Hub standards in every targeted account and region, then wait until each subscription is deleted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.DisableSecurityHubStandards(getSecurityHubTargets(), standards, standardsTimeout)
+	},
+}
+
+var securityHubStandardsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Report the status of Security Hub standards subscriptions",
+	Long:  "Report the status of every Security Hub standards subscription in every targeted account and region",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aws.ReportSecurityHubStandards(getSecurityHubTargets(), outputFormat)
+	},
+}
+
+func init() {
+	securityhubCmd.AddCommand(securityHubStandardsCmd)
+	securityHubStandardsCmd.AddCommand(securityHubStandardsListCmd)
+	securityHubStandardsCmd.AddCommand(securityHubStandardsEnableCmd)
+	securityHubStandardsCmd.AddCommand(securityHubStandardsDisableCmd)
+	securityHubStandardsCmd.AddCommand(securityHubStandardsStatusCmd)
+
+	addSecurityHubTargetFlags(securityHubStandardsCmd)
+
+	for _, cmd := range []*cobra.Command{securityHubStandardsEnableCmd, securityHubStandardsDisableCmd} {
+		cmd.Flags().StringSliceVarP(&standards, standardsFlag, "s", []string{}, "The standards to enable or disable")
+		cmd.Flags().DurationVar(&standardsTimeout, timeoutFlag, 5*time.Minute, "How long to wait for each account and region")
+		cmd.MarkFlagRequired(standardsFlag)
+	}
+
+	for _, cmd := range []*cobra.Command{securityHubStandardsListCmd, securityHubStandardsStatusCmd} {
+		cmd.Flags().StringVarP(&outputFormat, outputFlag, "o", output.FormatTable, "The output format: table, json or csv")
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MatchGlob reports whether s matches the shell-style pattern, where `*` matches any sequence of characters (including
+// `/`) and `?` matches a single character
+func MatchGlob(pattern string, s string) bool {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+
+	matched, err := regexp.MatchString("^"+expression+"$", s)
+	return err == nil && matched
+}
+
+// IsGlob reports whether the pattern contains any glob wildcards
+func IsGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
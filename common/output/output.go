@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Cloud Posse, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// These are the output formats supported by the reporting commands
+const (
+	FormatTable string = "table"
+	FormatJSON  string = "json"
+	FormatCSV   string = "csv"
+)
+
+// Table writes the headers and rows as tab-aligned columns
+func Table(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for i := range rows {
+		fmt.Fprintln(tw, strings.Join(rows[i], "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// CSV writes the headers and rows as comma separated values
+func CSV(w io.Writer, headers []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// JSON writes v as indented JSON
+func JSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+// Rows writes the headers and rows in the given format. JSON output is an array of objects keyed by the lower-cased
+// headers.
+func Rows(w io.Writer, format string, headers []string, rows [][]string) error {
+	switch format {
+	case FormatTable:
+		return Table(w, headers, rows)
+	case FormatCSV:
+		return CSV(w, headers, rows)
+	case FormatJSON:
+		objects := make([]map[string]string, 0, len(rows))
+		for i := range rows {
+			object := make(map[string]string)
+			for j := range headers {
+				object[strings.ToLower(headers[j])] = rows[i][j]
+			}
+			objects = append(objects, object)
+		}
+		return JSON(w, objects)
+	}
+
+	return ValidateFormat(format)
+}
+
+// ValidateFormat returns an error if the format isn't supported by Rows
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatCSV:
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid output format, must be one of %s, %s or %s", format, FormatTable, FormatJSON, FormatCSV)
+}